@@ -0,0 +1,262 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+)
+
+type arrayOpKind int
+
+const (
+	arrayOpRemoveAt arrayOpKind = iota
+	arrayOpInsertAt
+	arrayOpReplaceAt
+	arrayOpRemoveValue
+	arrayOpTruncate
+	arrayOpAppend // Append() called after an operation
+)
+
+// arrayOp is a pending operation over an array of scalars, strings or bytes. Applied on ToBytes()
+type arrayOp struct {
+	kind  arrayOpKind
+	idx   int
+	value interface{}
+}
+
+// RemoveAt removes element of an array of scalars, strings or bytes by index
+// Recorded as a pending operation and applied on ToBytes() after modifications made before by Set, Append, ApplyJSONAndToBytes, ApplyMapBuffer, ApplyMap
+// Index is out of range at the moment of applying -> error on ToBytes()
+// No such field or the field is not an array -> nothing happens. Array of nested objects -> error on ToBytes(), use GetMutableObjectArray() instead
+func (b *Buffer) RemoveAt(name string, idx int) {
+	b.addArrayOp(name, arrayOp{kind: arrayOpRemoveAt, idx: idx})
+}
+
+// InsertAt inserts element into an array of scalars, strings or bytes at index. idx == length -> the element is appended. See RemoveAt() for details
+// Value rules are the same as for elements on Set(). Wrong value -> error on ToBytes()
+func (b *Buffer) InsertAt(name string, idx int, value interface{}) {
+	b.addArrayOp(name, arrayOp{kind: arrayOpInsertAt, idx: idx, value: value})
+}
+
+// ReplaceAt replaces element of an array of scalars, strings or bytes by index. See RemoveAt() and InsertAt() for details
+func (b *Buffer) ReplaceAt(name string, idx int, value interface{}) {
+	b.addArrayOp(name, arrayOp{kind: arrayOpReplaceAt, idx: idx, value: value})
+}
+
+// RemoveValue removes all elements equal to value from an array of scalars, strings or bytes. See RemoveAt() and InsertAt() for details
+func (b *Buffer) RemoveValue(name string, value interface{}) {
+	b.addArrayOp(name, arrayOp{kind: arrayOpRemoveValue, value: value})
+}
+
+// Truncate keeps first `l` elements of an array of scalars, strings or bytes. See RemoveAt() for details
+// `l` >= length -> nothing happens, `l` is negative -> error on ToBytes(). Empty array is not stored
+func (b *Buffer) Truncate(name string, l int) {
+	b.addArrayOp(name, arrayOp{kind: arrayOpTruncate, idx: l})
+}
+
+func (b *Buffer) addArrayOp(name string, op arrayOp) {
+	f, ok := b.Scheme.FieldsMap[name]
+	if !ok || !f.IsArray {
+		return
+	}
+	b.prepareFieldsToBytes()
+	m := &b.fieldsToBytes[f.Order]
+	m.arrayOps = append(m.arrayOps, op)
+}
+
+// getArrayWithOps returns resulting typed array: stored array, modifications made by Set, Append, ApplyJSONAndToBytes, ApplyMapBuffer, ApplyMap, then pending operations
+func (b *Buffer) getArrayWithOps(f *Field, m *fieldToBytes) (interface{}, error) {
+	switch f.Ft {
+	case FieldTypeInt16:
+		return arrayOrNil(applyArrayOps[int16](b, f, m))
+	case FieldTypeInt32:
+		return arrayOrNil(applyArrayOps[int32](b, f, m))
+	case FieldTypeInt64:
+		return arrayOrNil(applyArrayOps[int64](b, f, m))
+	case FieldTypeFloat32:
+		return arrayOrNil(applyArrayOps[float32](b, f, m))
+	case FieldTypeFloat64:
+		return arrayOrNil(applyArrayOps[float64](b, f, m))
+	case FieldTypeBool:
+		return arrayOrNil(applyArrayOps[bool](b, f, m))
+	case FieldTypeByte:
+		return arrayOrNil(applyArrayOps[byte](b, f, m))
+	case FieldTypeString:
+		return arrayOrNil(applyArrayOps[string](b, f, m))
+	}
+	return nil, fmt.Errorf("array operations are not supported for array of nested objects %s, use GetMutableObjectArray() instead", f.QualifiedName())
+}
+
+// arrayOrNil avoids typed nil array in interface{} on error
+func arrayOrNil[T comparable](arr []T, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	return arr, nil
+}
+
+func applyArrayOps[T comparable](b *Buffer, f *Field, m *fieldToBytes) ([]T, error) {
+	var res []T
+	if !m.hasValue || (m.isAppend && !m.isNil()) {
+		if uOffsetT := b.getFieldUOffsetTByOrder(f.Order); uOffsetT != 0 {
+			res = append(res, b.getAllValues(uOffsetT, f).([]T)...) // copy, byte array is not copied by getAllValues()
+		}
+	}
+	if m.hasValue && !m.isNil() {
+		value := m.getValue()
+		arr, ok := toArrayOf[T](f, value)
+		if !ok {
+			return nil, fmt.Errorf("wrong value %T(%#v) provided for array field %s", value, value, f.QualifiedName())
+		}
+		res = append(res, arr...)
+	}
+	for _, op := range m.arrayOps {
+		var elem T
+		switch op.kind {
+		case arrayOpInsertAt, arrayOpReplaceAt, arrayOpRemoveValue:
+			var ok bool
+			if elem, ok = toArrayElemOf[T](f, op.value); !ok {
+				return nil, fmt.Errorf("wrong value %T(%#v) provided for element of array field %s", op.value, op.value, f.QualifiedName())
+			}
+		}
+		switch op.kind {
+		case arrayOpRemoveAt:
+			if op.idx < 0 || op.idx >= len(res) {
+				return nil, fmt.Errorf("%w: %d of %d for %s on RemoveAt()", ErrIndexOutOfRange, op.idx, len(res), f.QualifiedName())
+			}
+			res = append(res[:op.idx], res[op.idx+1:]...)
+		case arrayOpInsertAt:
+			if op.idx < 0 || op.idx > len(res) {
+				return nil, fmt.Errorf("%w: %d of %d for %s on InsertAt()", ErrIndexOutOfRange, op.idx, len(res), f.QualifiedName())
+			}
+			res = append(res, elem)
+			copy(res[op.idx+1:], res[op.idx:])
+			res[op.idx] = elem
+		case arrayOpReplaceAt:
+			if op.idx < 0 || op.idx >= len(res) {
+				return nil, fmt.Errorf("%w: %d of %d for %s on ReplaceAt()", ErrIndexOutOfRange, op.idx, len(res), f.QualifiedName())
+			}
+			res[op.idx] = elem
+		case arrayOpRemoveValue:
+			filtered := res[:0]
+			for _, v := range res {
+				if v != elem {
+					filtered = append(filtered, v)
+				}
+			}
+			res = filtered
+		case arrayOpTruncate:
+			if op.idx < 0 {
+				return nil, fmt.Errorf("negative length %d provided on Truncate() for %s", op.idx, f.QualifiedName())
+			}
+			if op.idx < len(res) {
+				res = res[:op.idx]
+			}
+		case arrayOpAppend:
+			if op.value == nil {
+				res = res[:0] // nil is appended -> unset
+				continue
+			}
+			arr, ok := toArrayOf[T](f, op.value)
+			if !ok {
+				return nil, fmt.Errorf("wrong value %T(%#v) provided for array field %s", op.value, op.value, f.QualifiedName())
+			}
+			if len(arr) == 0 {
+				res = res[:0] // empty array is appended -> unset
+				continue
+			}
+			res = append(res, arr...)
+		}
+	}
+	return res, nil
+}
+
+// toArrayOf converts value provided to Set() or Append() to []T
+func toArrayOf[T comparable](f *Field, value interface{}) ([]T, bool) {
+	switch arr := value.(type) {
+	case []T:
+		return arr, true
+	case []interface{}:
+		res := make([]T, len(arr))
+		for i, intf := range arr {
+			var ok bool
+			if res[i], ok = toArrayElemOf[T](f, intf); !ok {
+				return nil, false
+			}
+		}
+		return res, true
+	case [][]byte:
+		res := make([]T, len(arr))
+		for i, bytes := range arr {
+			var ok bool
+			if res[i], ok = interface{}(string(bytes)).(T); !ok {
+				return nil, false
+			}
+		}
+		return res, true
+	case string:
+		if f.Ft == FieldTypeByte {
+			// byte array could be provided as base64 string
+			bytes, err := base64.StdEncoding.DecodeString(arr)
+			if err != nil {
+				return nil, false
+			}
+			return interface{}(bytes).([]T), true
+		}
+	}
+	elem, ok := toArrayElemOf[T](f, value)
+	return []T{elem}, ok
+}
+
+// toArrayElemOf converts array element value to T
+// float64 (comes from JSON) and int are accepted for numeric types if fit, []byte is accepted for strings
+func toArrayElemOf[T comparable](f *Field, value interface{}) (T, bool) {
+	var res T
+	switch val := value.(type) {
+	case T:
+		return val, true
+	case float64:
+		if !IsFloat64ValueFitsIntoField(f, val) {
+			return res, false
+		}
+		switch f.Ft {
+		case FieldTypeInt16:
+			return interface{}(int16(val)).(T), true
+		case FieldTypeInt32:
+			return interface{}(int32(val)).(T), true
+		case FieldTypeInt64:
+			return interface{}(int64(val)).(T), true
+		case FieldTypeFloat32:
+			return interface{}(float32(val)).(T), true
+		case FieldTypeByte:
+			return interface{}(byte(val)).(T), true
+		}
+	case int:
+		switch {
+		case f.Ft == FieldTypeInt16 && val >= math.MinInt16 && val <= math.MaxInt16:
+			return interface{}(int16(val)).(T), true
+		case f.Ft == FieldTypeInt32 && val >= math.MinInt32 && val <= math.MaxInt32:
+			return interface{}(int32(val)).(T), true
+		case f.Ft == FieldTypeInt64:
+			return interface{}(int64(val)).(T), true
+		case f.Ft == FieldTypeFloat32:
+			return interface{}(float32(val)).(T), true
+		case f.Ft == FieldTypeFloat64:
+			return interface{}(float64(val)).(T), true
+		case f.Ft == FieldTypeByte && val >= 0 && val <= math.MaxUint8:
+			return interface{}(byte(val)).(T), true
+		}
+	case []byte:
+		if f.Ft == FieldTypeString {
+			return interface{}(string(val)).(T), true
+		}
+	}
+	return res, false
+}
@@ -0,0 +1,183 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var arraysSchemeYaml = `
+strs..: string
+ints..: int32
+longs..: int64
+floats..: float32
+bools..: bool
+bytes..: byte
+nested..:
+  id: int32
+`
+
+func TestArrayOps(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(arraysSchemeYaml)
+	require.NoError(err)
+
+	b := NewBuffer(s)
+	b.Set("strs", []string{"vip", "delivery", "takeaway", "vip"})
+	b.Set("ints", []int32{1, 2, 3})
+	b.Set("longs", []int64{1, 2, 3})
+	b.Set("floats", []float32{1, 2, 3})
+	b.Set("bools", []bool{true, false})
+	b.Set("bytes", []byte{1, 2, 3})
+	require.NoError(b.CommitChanges())
+	origBytes := copyBytes(b.GetBytes())
+
+	t.Run("stored array", func(t *testing.T) {
+		b.RemoveValue("strs", "vip")
+		b.InsertAt("strs", 0, "first")
+		b.ReplaceAt("strs", 2, []byte("last"))
+		b.RemoveAt("ints", 1)
+		b.InsertAt("ints", 2, int32(4))
+		b.InsertAt("ints", 0, float64(0)) // float64 comes from JSON
+		b.ReplaceAt("longs", 0, int64(10))
+		b.Truncate("longs", 2)
+		b.Truncate("floats", 5)
+		b.RemoveAt("bools", 0)
+		b.InsertAt("bytes", 3, byte(4))
+		b.RemoveAt("bytes", 0)
+		require.True(b.IsModified())
+		require.Equal(`{"strs":["first","delivery","last"],"ints":[0,1,3,4],"longs":[10,2],"floats":[1,2,3],"bools":[false],"bytes":"AgME"}`, string(b.ToJSON()))
+		require.Equal([]string{"first", "delivery", "last"}, b.ToJSONMap()["strs"])
+
+		bytes, err := b.ToBytes()
+		require.NoError(err)
+		bRead := ReadBuffer(bytes, s)
+		require.Equal([]string{"first", "delivery", "last"}, bRead.Get("strs"))
+		require.Equal([]int32{0, 1, 3, 4}, bRead.Get("ints"))
+		require.Equal([]int64{10, 2}, bRead.Get("longs"))
+		require.Equal([]float32{1, 2, 3}, bRead.Get("floats"))
+		require.Equal([]bool{false}, bRead.Get("bools"))
+		require.Equal([]byte{2, 3, 4}, bRead.Get("bytes"))
+		bRead.Release()
+
+		// source array is not damaged
+		require.Equal([]byte{1, 2, 3}, b.Get("bytes"))
+		b.Reset(origBytes)
+	})
+
+	t.Run("operations over Set() and Append()", func(t *testing.T) {
+		b.Append("ints", []int32{4, 5})
+		b.RemoveAt("ints", 0)
+		b.Append("ints", []int32{6})
+		b.Set("longs", []int64{7, 8})
+		b.RemoveValue("longs", int64(7))
+		bytes, err := b.ToBytes()
+		require.NoError(err)
+		bRead := ReadBuffer(bytes, s)
+		require.Equal([]int32{2, 3, 4, 5, 6}, bRead.Get("ints"))
+		require.Equal([]int64{8}, bRead.Get("longs"))
+		bRead.Release()
+
+		// Set() discards operations
+		b.Set("ints", []int32{9})
+		bytes, err = b.ToBytes()
+		require.NoError(err)
+		bRead = ReadBuffer(bytes, s)
+		require.Equal([]int32{9}, bRead.Get("ints"))
+		bRead.Release()
+		b.Reset(origBytes)
+	})
+
+	t.Run("empty result -> unset", func(t *testing.T) {
+		b.Truncate("ints", 0)
+		b.RemoveValue("bools", false)
+		b.RemoveValue("bools", true)
+		bytes, nilled, err := b.ToBytesNilled()
+		require.NoError(err)
+		require.Equal([]string{"ints", "bools"}, nilled)
+		bRead := ReadBuffer(bytes, s)
+		require.False(bRead.HasValue("ints"))
+		require.False(bRead.HasValue("bools"))
+		require.Equal([]string{"vip", "delivery", "takeaway", "vip"}, bRead.Get("strs"))
+		bRead.Release()
+		b.Reset(origBytes)
+	})
+
+	t.Run("int values as on Set()", func(t *testing.T) {
+		b.InsertAt("ints", 0, 5)
+		b.ReplaceAt("longs", 0, 10)
+		b.InsertAt("floats", 3, 4)
+		b.RemoveValue("bytes", 2)
+		bytes, err := b.ToBytes()
+		require.NoError(err)
+		bRead := ReadBuffer(bytes, s)
+		require.Equal([]int32{5, 1, 2, 3}, bRead.Get("ints"))
+		require.Equal([]int64{10, 2, 3}, bRead.Get("longs"))
+		require.Equal([]float32{1, 2, 3, 4}, bRead.Get("floats"))
+		require.Equal([]byte{1, 3}, bRead.Get("bytes"))
+		bRead.Release()
+		b.Reset(origBytes)
+
+		// out of range
+		b.InsertAt("ints", 0, math.MaxInt32+1)
+		_, err = b.ToBytes()
+		require.Error(err)
+		b.Reset(origBytes)
+		b.InsertAt("bytes", 0, -1)
+		_, err = b.ToBytes()
+		require.Error(err)
+		b.Reset(origBytes)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		b.RemoveAt("ints", 3)
+		_, err := b.ToBytes()
+		require.ErrorIs(err, ErrIndexOutOfRange)
+		b.Reset(origBytes)
+
+		b.InsertAt("ints", 4, int32(1))
+		_, err = b.ToBytes()
+		require.ErrorIs(err, ErrIndexOutOfRange)
+		b.Reset(origBytes)
+
+		b.ReplaceAt("ints", -1, int32(1))
+		_, err = b.ToBytes()
+		require.ErrorIs(err, ErrIndexOutOfRange)
+		b.Reset(origBytes)
+
+		b.InsertAt("ints", 0, "str")
+		_, err = b.ToBytes()
+		require.Error(err)
+		b.Reset(origBytes)
+
+		b.InsertAt("ints", 0, float64(1.5))
+		_, err = b.ToBytes()
+		require.Error(err)
+		b.Reset(origBytes)
+
+		b.Truncate("ints", -1)
+		_, err = b.ToBytes()
+		require.Error(err)
+		b.Reset(origBytes)
+
+		b.RemoveAt("nested", 0)
+		_, err = b.ToBytes()
+		require.Error(err)
+		b.Reset(origBytes)
+
+		// unknown field -> nothing happens
+		b.RemoveAt("unknown", 0)
+		require.False(b.IsModified())
+	})
+
+	b.Release()
+	require.Zero(GetObjectsInUse())
+}
@@ -0,0 +1,121 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"errors"
+	"fmt"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// Batch is a root object with the single field which is an array of nested objects of the same Scheme, see NewBatchScheme()
+// All elements are written by one builder so equal vtables are written once
+
+// BatchItemsField is the name of the field of the batch Scheme which contains elements
+const BatchItemsField = "items"
+
+// NewBatchScheme returns Scheme of the batch of objects of the provided Scheme. Useful to Verify() a batch or to read it as a Buffer
+func NewBatchScheme(scheme *Scheme) *Scheme {
+	return NewScheme().AddNestedArray(BatchItemsField, scheme, false)
+}
+
+// BatchBuilder encodes many Buffers of the same Scheme into one batch
+// Not goroutine-safe
+type BatchBuilder struct {
+	scheme  *Scheme
+	builder *flatbuffers.Builder
+	scratch *flatbuffers.Builder // a Buffer is encoded here first so a failed encoding does not damage the batch
+	items   []flatbuffers.UOffsetT
+	err     error
+}
+
+// NewBatchBuilder creates BatchBuilder of Buffers of the Scheme
+func NewBatchBuilder(scheme *Scheme) *BatchBuilder {
+	return &BatchBuilder{
+		scheme:  scheme,
+		builder: flatbuffers.NewBuilder(0),
+		scratch: flatbuffers.NewBuilder(0),
+	}
+}
+
+// Add encodes the Buffer as ToBytes() does. Empty Buffer is encoded as an object without fields
+// Error is returned if the Buffer has another Scheme or could not be encoded. The Buffer is not added then, the batch could be built further
+// The Buffer is encoded twice: into a scratch builder to check it could be encoded, then into the batch. Second encoding fails (e.g. a
+// FieldTransformer fails randomly) -> the batch is broken: the error is returned by further Add() calls and Finish() returns nil until Reset()
+func (bb *BatchBuilder) Add(b *Buffer) error {
+	if bb.err != nil {
+		return bb.err
+	}
+	if b.Scheme != bb.scheme {
+		return errors.New("scheme of the Buffer does not match the scheme of the batch")
+	}
+	bb.scratch.Reset()
+	if _, err := b.encodeBuffer(bb.scratch); err != nil {
+		return err
+	}
+	uOffsetT, err := b.encodeBuffer(bb.builder)
+	if err != nil {
+		bb.err = fmt.Errorf("batch is broken: %w", err)
+		return bb.err
+	}
+	if uOffsetT == 0 {
+		bb.builder.StartObject(0)
+		uOffsetT = bb.builder.EndObject()
+	}
+	bb.items = append(bb.items, uOffsetT)
+	return nil
+}
+
+// Len returns count of added Buffers
+func (bb *BatchBuilder) Len() int {
+	return len(bb.items)
+}
+
+// Finish returns bytes of the batch. Bytes are valid until Reset(). nil if nothing is added or the batch is broken, see Add()
+// Must be called once, use Reset() to build the next batch
+func (bb *BatchBuilder) Finish() []byte {
+	if len(bb.items) == 0 || bb.err != nil {
+		return nil
+	}
+	// elements are stored in reverse order
+	bb.builder.StartVector(flatbuffers.SizeUOffsetT, len(bb.items), flatbuffers.SizeUOffsetT)
+	for _, item := range bb.items {
+		bb.builder.PrependUOffsetT(item)
+	}
+	itemsUOffsetT := bb.builder.EndVector(len(bb.items))
+	bb.builder.StartObject(1)
+	bb.builder.PrependUOffsetTSlot(0, itemsUOffsetT, 0)
+	bb.builder.Finish(bb.builder.EndObject())
+	return bb.builder.FinishedBytes()
+}
+
+// Reset clears added Buffers to build the next batch. Bytes returned by Finish() are damaged
+func (bb *BatchBuilder) Reset() {
+	bb.builder.Reset()
+	bb.items = bb.items[:0]
+	bb.err = nil
+}
+
+// ReadBatch returns elements of the batch made by BatchBuilder. Use ObjectArray.Next() to iterate or ObjectArray.At() for random access
+// Empty bytes -> empty ObjectArray. The result must be released by the caller
+func ReadBatch(bytes []byte, scheme *Scheme) *ObjectArray {
+	res := getObjectArray()
+	res.Buffer = NewBuffer(scheme)
+	res.Buffer.tab.Bytes = bytes
+	res.Len = 0
+	res.curElem = -1
+	if len(bytes) > 0 {
+		root := flatbuffers.Table{Bytes: bytes, Pos: flatbuffers.GetUOffsetT(bytes)}
+		if itemsVOffsetT := flatbuffers.UOffsetT(root.Offset(flatbuffers.VOffsetT(2 * 2))); itemsVOffsetT != 0 {
+			res.Len = root.VectorLen(itemsVOffsetT)
+			res.start = root.Vector(itemsVOffsetT)
+		}
+	}
+	return res
+}
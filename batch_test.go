@@ -0,0 +1,175 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatch(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(orderSchemeYaml)
+	require.NoError(err)
+	orderBytes := getOrderBytes(t, s)
+
+	bb := NewBatchBuilder(s)
+	b := ReadBuffer(orderBytes, s)
+	separateSize := 0
+	for i := 0; i < 500; i++ {
+		b.Set("name", fmt.Sprintf("order%d", i))
+		require.NoError(bb.Add(b))
+		bytes, err := b.ToBytes()
+		require.NoError(err)
+		separateSize += len(bytes)
+	}
+	b.Release()
+	b = NewBuffer(s)
+	require.NoError(bb.Add(b)) // empty
+	b.Release()
+	require.Equal(501, bb.Len())
+	batchBytes := copyBytes(bb.Finish())
+	// vtables are shared
+	require.Less(len(batchBytes), separateSize)
+
+	arr := ReadBatch(batchBytes, s)
+	require.Equal(501, arr.Len)
+	for i := 0; arr.Next(); i++ {
+		if i < 500 {
+			require.Equal(fmt.Sprintf("order%d", i), arr.Buffer.Get("name"))
+			require.Equal(int64(20), arr.Buffer.Get("lines").(*ObjectArray).At(1).Get("article").(*Buffer).Get("id"))
+		} else {
+			require.Nil(arr.Buffer.Get("name"))
+		}
+	}
+	require.Equal("order42", arr.At(42).Get("name"))
+	require.True(arr.Next())
+	require.Equal("order43", arr.Buffer.Get("name"))
+	require.Panics(func() { arr.At(501) })
+	arr.Release()
+
+	// batch is a regular object
+	batchScheme := NewBatchScheme(s)
+	require.NoError(Verify(batchBytes, batchScheme))
+	bBatch := ReadBuffer(batchBytes, batchScheme)
+	require.Equal(501, bBatch.Get(BatchItemsField).(*ObjectArray).Len)
+	bBatch.Release()
+
+	// reuse
+	bb.Reset()
+	require.Nil(bb.Finish())
+	arr = ReadBatch(nil, s)
+	require.Zero(arr.Len)
+	require.False(arr.Next())
+	arr.Release()
+	b = ReadBuffer(orderBytes, s)
+	require.NoError(bb.Add(b))
+	b.Release()
+	arr = ReadBatch(bb.Finish(), s)
+	require.Equal(1, arr.Len)
+	require.Equal("order", arr.At(0).Get("name"))
+	arr.Release()
+
+	// wrong scheme
+	b = NewBuffer(batchScheme)
+	require.Error(bb.Add(b))
+	b.Release()
+
+	require.Zero(GetObjectsInUse())
+}
+
+func TestBatchAddAfterError(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(`
+name: string
+qty: int32
+ids..: int64
+`)
+	require.NoError(err)
+
+	bb := NewBatchBuilder(s)
+	for i := 0; i < 100; i++ {
+		b := NewBuffer(s)
+		b.Set("name", fmt.Sprintf("order%d", i))
+		require.NoError(bb.Add(b))
+		b.Release()
+
+		// errors in the middle of an object and of a vector. Large data makes the builder grow before the error
+		b = NewBuffer(s)
+		b.Set("name", fmt.Sprintf("%01000d", i))
+		if i%2 == 0 {
+			b.Set("qty", "wrong")
+		} else {
+			b.Set("ids", []interface{}{float64(1), "wrong"})
+		}
+		require.Error(bb.Add(b))
+		b.Release()
+	}
+	require.Equal(100, bb.Len())
+
+	batchBytes := copyBytes(bb.Finish())
+	require.NoError(Verify(batchBytes, NewBatchScheme(s)))
+	arr := ReadBatch(batchBytes, s)
+	require.Equal(100, arr.Len)
+	for i := 0; arr.Next(); i++ {
+		require.Equal(fmt.Sprintf("order%d", i), arr.Buffer.Get("name"))
+		require.Nil(arr.Buffer.Get("qty"))
+	}
+	arr.Release()
+
+	require.Zero(GetObjectsInUse())
+}
+
+// encodeOnceTransformer fails on the second Encode() call
+type encodeOnceTransformer struct {
+	calls int
+}
+
+func (et *encodeOnceTransformer) Encode(src []byte) ([]byte, error) {
+	et.calls++
+	if et.calls > 1 {
+		return nil, errors.New("encode failed")
+	}
+	return append([]byte{}, src...), nil
+}
+
+func (et *encodeOnceTransformer) Decode(src []byte) ([]byte, error) {
+	return append([]byte{}, src...), nil
+}
+
+func TestBatchBroken(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme("name: string\nsecret: string")
+	require.NoError(err)
+	bb := NewBatchBuilder(s)
+	b := NewBuffer(s)
+	b.Set("name", "order")
+	require.NoError(bb.Add(b))
+
+	// passed the check on the scratch builder but failed on the batch
+	require.NoError(s.FieldsMap["secret"].SetTransformer(&encodeOnceTransformer{}))
+	b.Set("secret", "pin")
+	require.ErrorIs(bb.Add(b), ErrTransform)
+	b.Set("secret", nil)
+	require.ErrorIs(bb.Add(b), ErrTransform)
+	require.Nil(bb.Finish())
+
+	bb.Reset()
+	require.NoError(bb.Add(b))
+	arr := ReadBatch(bb.Finish(), s)
+	require.Equal(1, arr.Len)
+	require.True(arr.Next())
+	require.Equal("order", arr.Buffer.Get("name"))
+	arr.Release()
+	b.Release()
+
+	require.Zero(GetObjectsInUse())
+}
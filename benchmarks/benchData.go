@@ -863,6 +863,16 @@ quantity: int32
 	return s
 }
 
+func getSimpleArrayScheme() *dynobuffers.Scheme {
+	s, _ := dynobuffers.YamlToScheme(`
+name: string
+price: float32
+quantity: int32
+ids..: int64
+`)
+	return s
+}
+
 func getNestedScheme() *dynobuffers.Scheme {
 	s, err := dynobuffers.YamlToScheme(`
 ViewMods..:
@@ -327,6 +327,30 @@ func Benchmark_R_Article_FewFields_Dyno_Typed(b *testing.B) {
 	})
 	require.Zero(b, dynobuffers.GetObjectsInUse())
 }
+
+func Benchmark_R_Article_FewFields_Dyno_TypedF(b *testing.B) {
+	s := getArticleSchemeDynoBuffer()
+	bf := dynobuffers.NewBuffer(s)
+	fillArticleDynoBuffer(bf)
+	bytes, _ := bf.ToBytes()
+	bytes = copyBytes(bytes)
+	bf.Release()
+	fQuantity := s.Field("quantity")
+	fPrice := s.Field("purchase_price")
+	b.ResetTimer()
+	b.RunParallel(func(p *testing.PB) {
+		sum := float64(0)
+		for p.Next() {
+			bf := dynobuffers.ReadBuffer(bytes, s)
+			q, _ := bf.GetInt32F(fQuantity)
+			price, _ := bf.GetFloat32F(fPrice)
+			sum += float64(float32(q) * price)
+			bf.Release()
+		}
+	})
+	require.Zero(b, dynobuffers.GetObjectsInUse())
+}
+
 func Benchmark_R_Article_FewFields_Flat(b *testing.B) {
 	bl := flatbuffers.NewBuilder(0)
 	a := fillArticleFlatBuffers(bl)
@@ -1071,3 +1095,30 @@ func Benchmark_R_Article_AllFields_Json(b *testing.B) {
 		}
 	})
 }
+
+func Benchmark_R_Columns_Dyno(b *testing.B) {
+	s := getSimpleScheme()
+	payloads := [][]byte{}
+	for i := 0; i < 1000; i++ {
+		bf := dynobuffers.NewBuffer(s)
+		bf.Set("name", "cola")
+		bf.Set("price", float32(0.123))
+		bf.Set("quantity", int32(i))
+		bytes, err := bf.ToBytes()
+		require.NoError(b, err)
+		payloads = append(payloads, copyBytes(bytes))
+		bf.Release()
+	}
+	ce, err := dynobuffers.NewColumnExtractor(s, "price", "quantity")
+	require.NoError(b, err)
+
+	b.ResetTimer()
+	sum := float64(0)
+	for i := 0; i < b.N; i++ {
+		ce.Extract(payloads)
+		prices, quantities := ce.Columns[0].Float64s, ce.Columns[1].Int64s
+		for row := range payloads {
+			sum += prices[row] * float64(quantities[row])
+		}
+	}
+}
@@ -55,6 +55,63 @@ func Benchmark_Fill_ToBytes_Simple_Dyno(b *testing.B) {
 	require.Zero(b, dynobuffers.GetObjectsInUse())
 }
 
+// values are not constants, so Set() boxes them into interface{} and allocates
+var simpleValues = []struct {
+	name     string
+	price    float32
+	quantity int32
+	ids      []int64
+}{
+	{"cola", 0.123, 42000, []int64{1, 2, 3}},
+	{"fanta", 1.5, 100500, []int64{4, 5}},
+}
+
+func Benchmark_Fill_ToBytes_Simple_Dyno_Boxed(b *testing.B) {
+	s := getSimpleArrayScheme()
+
+	b.ReportAllocs()
+	b.RunParallel(func(p *testing.PB) {
+		i := 0
+		for p.Next() {
+			v := simpleValues[i%len(simpleValues)]
+			bf := dynobuffers.NewBuffer(s)
+			bf.Set("name", v.name)
+			bf.Set("price", v.price)
+			bf.Set("quantity", v.quantity)
+			bf.Set("ids", v.ids)
+			if _, err := bf.ToBytes(); err != nil {
+				b.Fatal(err)
+			}
+			bf.Release()
+			i++
+		}
+	})
+	require.Zero(b, dynobuffers.GetObjectsInUse())
+}
+
+func Benchmark_Fill_ToBytes_Simple_Dyno_Typed(b *testing.B) {
+	s := getSimpleArrayScheme()
+
+	b.ReportAllocs()
+	b.RunParallel(func(p *testing.PB) {
+		i := 0
+		for p.Next() {
+			v := simpleValues[i%len(simpleValues)]
+			bf := dynobuffers.NewBuffer(s)
+			bf.SetString("name", v.name)
+			bf.SetFloat32("price", v.price)
+			bf.SetInt32("quantity", v.quantity)
+			bf.SetInt64Array("ids", v.ids)
+			if _, err := bf.ToBytes(); err != nil {
+				b.Fatal(err)
+			}
+			bf.Release()
+			i++
+		}
+	})
+	require.Zero(b, dynobuffers.GetObjectsInUse())
+}
+
 func Benchmark_MapToBytes_Nested_Dyno(b *testing.B) {
 	s := getNestedScheme()
 	data := getNestedData()
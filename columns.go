@@ -0,0 +1,147 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"fmt"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// Column contains values of a field of many payloads, row i is the value of payload i
+// Only one of the typed slices is filled depending on the field type:
+//   - Int64s: int16, int32, int64, byte
+//   - Float64s: float32, float64
+//   - Bools: bool
+//   - Strings: string. Strings refer to payloads bytes
+//
+// Unset value -> zero value and the row bit is not set in Present
+type Column struct {
+	Field    *Field
+	Int64s   []int64
+	Float64s []float64
+	Bools    []bool
+	Strings  []string
+	Present  []uint64 // bitmap, row i -> bit i%64 of Present[i/64]
+}
+
+// IsPresent returns true if the value is set for the row
+func (c *Column) IsPresent(row int) bool {
+	return c.Present[row/64]&(1<<(row%64)) != 0
+}
+
+// ColumnExtractor reads values of a few fields of many payloads of the same Scheme into typed columns
+// Payloads are not checked, use Verify() for untrusted bytes. Slices of columns are reused by the next Extract()
+// Not goroutine-safe
+type ColumnExtractor struct {
+	Columns []Column
+	slots   []flatbuffers.VOffsetT
+}
+
+// NewColumnExtractor creates ColumnExtractor for the fields of the Scheme. Columns are in the order of names
+// Error wraps ErrUnknownField if there is no such field in the Scheme, ErrPathMismatch if the field is an array or a nested object
+func NewColumnExtractor(scheme *Scheme, names ...string) (*ColumnExtractor, error) {
+	res := &ColumnExtractor{
+		Columns: make([]Column, len(names)),
+		slots:   make([]flatbuffers.VOffsetT, len(names)),
+	}
+	for i, name := range names {
+		f, ok := scheme.FieldsMap[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownField, name)
+		}
+		if f.IsArray || f.Ft == FieldTypeObject {
+			return nil, fmt.Errorf("%w: field %s is not a scalar or a string", ErrPathMismatch, f.QualifiedName())
+		}
+		res.Columns[i].Field = f
+		res.slots[i] = flatbuffers.VOffsetT((f.Order + 2) * 2)
+	}
+	return res, nil
+}
+
+// Column returns the column by field name, nil if there is no such column
+func (ce *ColumnExtractor) Column(name string) *Column {
+	for i := range ce.Columns {
+		if ce.Columns[i].Field.Name == name {
+			return &ce.Columns[i]
+		}
+	}
+	return nil
+}
+
+// Extract fills columns by values of payloads. Columns have len(payloads) rows. Empty payload -> all values are unset
+func (ce *ColumnExtractor) Extract(payloads [][]byte) {
+	rows := len(payloads)
+	for i := range ce.Columns {
+		ce.Columns[i].reset(rows)
+	}
+	tab := flatbuffers.Table{}
+	for row, bytes := range payloads {
+		if len(bytes) == 0 {
+			continue
+		}
+		tab.Bytes = bytes
+		tab.Pos = flatbuffers.GetUOffsetT(bytes)
+		vtable := flatbuffers.UOffsetT(flatbuffers.SOffsetT(tab.Pos) - tab.GetSOffsetT(tab.Pos))
+		vtableSize := tab.GetVOffsetT(vtable)
+		for i, slot := range ce.slots {
+			if slot >= vtableSize {
+				continue
+			}
+			vOffsetT := tab.GetVOffsetT(vtable + flatbuffers.UOffsetT(slot))
+			if vOffsetT == 0 {
+				continue
+			}
+			c := &ce.Columns[i]
+			pos := tab.Pos + flatbuffers.UOffsetT(vOffsetT)
+			switch c.Field.Ft {
+			case FieldTypeInt16:
+				c.Int64s[row] = int64(tab.GetInt16(pos))
+			case FieldTypeInt32:
+				c.Int64s[row] = int64(tab.GetInt32(pos))
+			case FieldTypeInt64:
+				c.Int64s[row] = tab.GetInt64(pos)
+			case FieldTypeByte:
+				c.Int64s[row] = int64(tab.GetByte(pos))
+			case FieldTypeFloat32:
+				c.Float64s[row] = float64(tab.GetFloat32(pos))
+			case FieldTypeFloat64:
+				c.Float64s[row] = tab.GetFloat64(pos)
+			case FieldTypeBool:
+				c.Bools[row] = tab.GetBool(pos)
+			case FieldTypeString:
+				c.Strings[row] = byteSliceToString(tab.ByteVector(pos))
+			}
+			c.Present[row/64] |= 1 << (row % 64)
+		}
+	}
+}
+
+// reset makes zeroed column of `rows` rows reusing slices
+func (c *Column) reset(rows int) {
+	switch c.Field.Ft {
+	case FieldTypeInt16, FieldTypeInt32, FieldTypeInt64, FieldTypeByte:
+		c.Int64s = resetColumnSlice(c.Int64s, rows)
+	case FieldTypeFloat32, FieldTypeFloat64:
+		c.Float64s = resetColumnSlice(c.Float64s, rows)
+	case FieldTypeBool:
+		c.Bools = resetColumnSlice(c.Bools, rows)
+	case FieldTypeString:
+		c.Strings = resetColumnSlice(c.Strings, rows)
+	}
+	c.Present = resetColumnSlice(c.Present, (rows+63)/64)
+}
+
+func resetColumnSlice[T any](s []T, l int) []T {
+	if cap(s) < l {
+		return make([]T, l)
+	}
+	s = s[:l]
+	clear(s)
+	return s
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnExtractor(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(`
+name: string
+qty: int32
+price: float32
+total: float64
+isPaid: bool
+code: byte
+short: int16
+id: int64
+article:
+  id: int64
+`)
+	require.NoError(err)
+
+	payloads := [][]byte{}
+	for i := 0; i < 100; i++ {
+		b := NewBuffer(s)
+		if i%3 != 0 {
+			b.Set("name", fmt.Sprintf("name%d", i))
+			b.Set("qty", int32(i))
+			b.Set("isPaid", i%2 == 0)
+		}
+		b.Set("price", float32(i)/2)
+		b.Set("total", float64(i)*1.5)
+		b.Set("code", byte(i))
+		b.Set("short", int16(-i))
+		b.Set("id", int64(i)<<40)
+		bytes, err := b.ToBytes()
+		require.NoError(err)
+		payloads = append(payloads, copyBytes(bytes))
+		b.Release()
+	}
+	payloads = append(payloads, nil)
+
+	ce, err := NewColumnExtractor(s, "qty", "name", "price", "total", "isPaid", "code", "short", "id")
+	require.NoError(err)
+	for i := 0; i < 2; i++ { // slices are reused
+		ce.Extract(payloads)
+		for row, bytes := range payloads {
+			b := ReadBuffer(bytes, s)
+			for _, c := range ce.Columns {
+				value := b.Get(c.Field.Name)
+				require.Equal(value != nil, c.IsPresent(row), row)
+				if value == nil {
+					continue
+				}
+				switch typed := value.(type) {
+				case int16:
+					require.Equal(int64(typed), c.Int64s[row])
+				case int32:
+					require.Equal(int64(typed), c.Int64s[row])
+				case int64:
+					require.Equal(typed, c.Int64s[row])
+				case byte:
+					require.Equal(int64(typed), c.Int64s[row])
+				case float32:
+					require.Equal(float64(typed), c.Float64s[row])
+				case float64:
+					require.Equal(typed, c.Float64s[row])
+				case bool:
+					require.Equal(typed, c.Bools[row])
+				case string:
+					require.Equal(typed, c.Strings[row])
+				}
+			}
+			b.Release()
+		}
+		require.Len(ce.Column("qty").Int64s, len(payloads))
+		require.Zero(ce.Column("qty").Int64s[99])
+		require.Nil(ce.Column("qty").Float64s)
+		require.Equal([]string{"", "name1"}, ce.Column("name").Strings[:2])
+	}
+	ce.Extract(payloads[:1])
+	require.Len(ce.Column("name").Strings, 1)
+	require.False(ce.Column("name").IsPresent(0))
+	require.Nil(ce.Column("unknown"))
+
+	_, err = NewColumnExtractor(s, "unknown")
+	require.ErrorIs(err, ErrUnknownField)
+	_, err = NewColumnExtractor(s, "article")
+	require.ErrorIs(err, ErrPathMismatch)
+
+	require.Zero(GetObjectsInUse())
+}
@@ -0,0 +1,220 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"bytes"
+	"cmp"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// Equal returns true if both Buffers have the same values. Pending modifications are considered
+// Fields are matched by name so Buffers of different Schemes could be compared. Field which does not exist in a Scheme is considered as unset
+// Unset and empty strings, arrays and nested objects are equal because empty values are not stored. Floats are compared by value, NaN equals to NaN
+// Nested objects and arrays are compared recursively. nil Buffer equals to an empty one
+// Pending modifications of any Buffer could not be encoded, i.e. ToBytes() fails -> false
+func (b *Buffer) Equal(other *Buffer) bool {
+	res, err := b.Compare(other)
+	return err == nil && res == 0
+}
+
+// Compare returns -1, 0 or +1 depending on whether the Buffer is less than, equal to or greater than `other` by values of the listed fields
+// `fields` are empty -> all fields in order of the Buffer's Scheme are compared, then fields of `other` Scheme which are absent in the Buffer's Scheme
+// Unset value is less than any set value. Strings are compared lexicographically, false is less than true
+// Arrays are compared element by element, then by length. Nested objects are compared by all fields
+// Unknown field is considered as unset. Field types differ -> compared by field types. See Equal() for details
+// Returns error if pending modifications of any Buffer could not be encoded
+func (b *Buffer) Compare(other *Buffer, fields ...string) (int, error) {
+	bView, bRelease, err := b.committedView()
+	if err != nil {
+		return 0, err
+	}
+	defer bRelease()
+	otherView, otherRelease, err := other.committedView()
+	if err != nil {
+		return 0, err
+	}
+	defer otherRelease()
+	return compareBuffers(bView, otherView, fields), nil
+}
+
+// committedView returns Buffer which bytes contain pending modifications and func to release it
+// Returns the encoding error if pending modifications could not be encoded
+func (b *Buffer) committedView() (*Buffer, func(), error) {
+	if b == nil || !b.IsModified() {
+		return b, func() {}, nil
+	}
+	res, err := b.Clone()
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, res.Release, nil
+}
+
+func compareBuffers(a, b *Buffer, fields []string) int {
+	if len(fields) > 0 {
+		for _, name := range fields {
+			if c := compareFields(a, b, name); c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+	if a != nil {
+		for _, f := range a.Scheme.Fields {
+			if c := compareFields(a, b, f.Name); c != 0 {
+				return c
+			}
+		}
+	}
+	if b != nil {
+		for _, f := range b.Scheme.Fields {
+			if a != nil {
+				if _, ok := a.Scheme.FieldsMap[f.Name]; ok {
+					continue
+				}
+			}
+			if c := compareFields(a, b, f.Name); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+// storedField returns field and offset of its stored value, 0 offset if the value is unset
+func (b *Buffer) storedField(name string) (*Field, flatbuffers.UOffsetT) {
+	if b == nil {
+		return nil, 0
+	}
+	f, ok := b.Scheme.FieldsMap[name]
+	if !ok {
+		return nil, 0
+	}
+	return f, b.getFieldUOffsetTByOrder(f.Order)
+}
+
+func compareFields(a, b *Buffer, name string) int {
+	fa, oa := a.storedField(name)
+	fb, ob := b.storedField(name)
+	switch {
+	case oa == 0 && ob == 0:
+		return 0
+	case oa == 0:
+		return -1
+	case ob == 0:
+		return 1
+	}
+	if c := cmp.Compare(fa.Ft, fb.Ft); c != 0 {
+		return c
+	}
+	if fa.IsArray != fb.IsArray {
+		if fa.IsArray {
+			return 1
+		}
+		return -1
+	}
+	if fa.IsArray {
+		return compareArrays(a, b, fa, fb, oa, ob)
+	}
+	switch fa.Ft {
+	case FieldTypeInt16:
+		return cmp.Compare(a.tab.GetInt16(oa), b.tab.GetInt16(ob))
+	case FieldTypeInt32:
+		return cmp.Compare(a.tab.GetInt32(oa), b.tab.GetInt32(ob))
+	case FieldTypeInt64:
+		return cmp.Compare(a.tab.GetInt64(oa), b.tab.GetInt64(ob))
+	case FieldTypeFloat32:
+		return cmp.Compare(a.tab.GetFloat32(oa), b.tab.GetFloat32(ob))
+	case FieldTypeFloat64:
+		return cmp.Compare(a.tab.GetFloat64(oa), b.tab.GetFloat64(ob))
+	case FieldTypeByte:
+		return cmp.Compare(a.tab.GetByte(oa), b.tab.GetByte(ob))
+	case FieldTypeBool:
+		return compareBools(a.tab.GetBool(oa), b.tab.GetBool(ob))
+	case FieldTypeString:
+		return bytes.Compare(a.tab.ByteVector(oa), b.tab.ByteVector(ob))
+	default:
+		nestedA := a.readNested(fa, a.tab.Indirect(oa))
+		nestedB := b.readNested(fb, b.tab.Indirect(ob))
+		res := compareBuffers(nestedA, nestedB, nil)
+		nestedA.Release()
+		nestedB.Release()
+		return res
+	}
+}
+
+func compareArrays(a, b *Buffer, fa, fb *Field, oa, ob flatbuffers.UOffsetT) int {
+	switch fa.Ft {
+	case FieldTypeInt16:
+		return compareSlices(getImplIInt16Array(a, oa), getImplIInt16Array(b, ob), cmp.Compare[int16])
+	case FieldTypeInt32:
+		return compareSlices(getImplIInt32Array(a, oa), getImplIInt32Array(b, ob), cmp.Compare[int32])
+	case FieldTypeInt64:
+		return compareSlices(getImplIInt64Array(a, oa), getImplIInt64Array(b, ob), cmp.Compare[int64])
+	case FieldTypeFloat32:
+		return compareSlices(getImplIFloat32Array(a, oa), getImplIFloat32Array(b, ob), cmp.Compare[float32])
+	case FieldTypeFloat64:
+		return compareSlices(getImplIFloat64Array(a, oa), getImplIFloat64Array(b, ob), cmp.Compare[float64])
+	case FieldTypeBool:
+		return compareSlices(getImplIBoolArray(a, oa), getImplIBoolArray(b, ob), compareBools)
+	case FieldTypeString:
+		return compareSlices(getImplIStringArray(a, oa), getImplIStringArray(b, ob), cmp.Compare[string])
+	case FieldTypeByte:
+		return bytes.Compare(getImplIByteArray(a, oa).Bytes(), getImplIByteArray(b, ob).Bytes())
+	}
+	// array of nested objects, single reader Buffer per array
+	la := a.tab.VectorLen(oa - a.tab.Pos)
+	lb := b.tab.VectorLen(ob - b.tab.Pos)
+	startA := a.tab.Vector(oa - a.tab.Pos)
+	startB := b.tab.Vector(ob - b.tab.Pos)
+	elemA := a.readNested(fa, 0)
+	defer elemA.Release()
+	elemB := b.readNested(fb, 0)
+	defer elemB.Release()
+	for i := 0; i < la && i < lb; i++ {
+		elemA.tab.Pos = a.tab.Indirect(startA + flatbuffers.UOffsetT(la-1-i)*flatbuffers.SizeUOffsetT)
+		elemB.tab.Pos = b.tab.Indirect(startB + flatbuffers.UOffsetT(lb-1-i)*flatbuffers.SizeUOffsetT)
+		if c := compareBuffers(elemA, elemB, nil); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(la, lb)
+}
+
+// readNested returns Buffer to read nested object at `pos` of the Buffer's bytes. Must be released by the caller
+func (b *Buffer) readNested(f *Field, pos flatbuffers.UOffsetT) *Buffer {
+	res := NewBuffer(f.FieldScheme)
+	res.tab.Bytes = b.tab.Bytes
+	res.tab.Pos = pos
+	return res
+}
+
+func compareSlices[T any](a, b interface {
+	Len() int
+	At(idx int) T
+}, compare func(T, T) int) int {
+	for i := 0; i < a.Len() && i < b.Len(); i++ {
+		if c := compare(a.At(i), b.At(i)); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(a.Len(), b.Len())
+}
+
+func compareBools(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
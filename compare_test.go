@@ -0,0 +1,152 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEqual(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(orderSchemeYaml)
+	require.NoError(err)
+	orderBytes := getOrderBytes(t, s)
+
+	b1 := ReadBuffer(orderBytes, s)
+	b2 := ReadBuffer(copyBytes(orderBytes), s)
+	require.True(b1.Equal(b2))
+	require.Zero(compare(t, b1, b2))
+
+	// pending modifications are considered
+	b2.Set("name", "other")
+	require.False(b1.Equal(b2))
+	b2.Set("name", "order")
+	require.True(b1.Equal(b2))
+
+	// nested objects and arrays of nested objects
+	b2.GetMutableObjectArray("lines").At(1).Get("article").(*Buffer).Set("name", "other")
+	require.False(b1.Equal(b2))
+	b2.Release()
+	b2 = ReadBuffer(orderBytes, s)
+	b2.GetMutableObjectArray("lines").Truncate(1)
+	require.False(b1.Equal(b2))
+	require.Equal(1, compare(t, b1, b2, "lines"))
+	b2.Release()
+	b2 = ReadBuffer(orderBytes, s)
+	b2.RemoveAt("tags", 1)
+	require.False(b1.Equal(b2))
+	b2.Release()
+
+	// unset equals to empty
+	b1.Release()
+	b1 = NewBuffer(s)
+	b2 = NewBuffer(s)
+	b2.Set("name", "")
+	b2.Set("tags", []string{})
+	b2.Set("article", NewBuffer(s.GetNestedScheme("article")))
+	require.True(b1.Equal(b2))
+	require.True(b1.Equal(nil))
+	var bNil *Buffer
+	require.True(bNil.Equal(b2))
+
+	// NaN equals to NaN
+	b1.Set("lines", []*Buffer{NewBuffer(s.GetNestedScheme("lines"))})
+	b1.GetMutableObjectArray("lines").At(0).Set("price", math.NaN())
+	b2.Set("lines", []*Buffer{NewBuffer(s.GetNestedScheme("lines"))})
+	b2.GetMutableObjectArray("lines").At(0).Set("price", math.NaN())
+	require.True(b1.Equal(b2))
+	b1.Release()
+	b2.Release()
+
+	// different schemes, fields are matched by name, absent field is unset
+	sOther, err := YamlToScheme(`
+name: string
+extra: int32
+`)
+	require.NoError(err)
+	b1 = NewBuffer(s)
+	b1.Set("name", "str")
+	b2 = NewBuffer(sOther)
+	b2.Set("name", "str")
+	require.True(b1.Equal(b2))
+	b2.Set("extra", int32(1))
+	require.False(b1.Equal(b2))
+	require.Equal(-1, compare(t, b1, b2))
+	require.Equal(1, compare(t, b2, b1))
+	b1.Release()
+	b2.Release()
+
+	// modifications which could not be encoded -> not equal, error on Compare(), Diff(), MergePatch() and Project()
+	b1 = ReadBuffer(orderBytes, s)
+	b2 = ReadBuffer(orderBytes, s)
+	b2.Set("name", 42)
+	require.False(b1.Equal(b2))
+	require.False(b2.Equal(b1))
+	_, err = b1.Compare(b2)
+	require.Error(err)
+	_, err = b2.Compare(b1)
+	require.Error(err)
+	_, err = Diff(b1, b2)
+	require.Error(err)
+	_, err = MergePatch(b1, b2)
+	require.Error(err)
+	_, err = b2.Project("name")
+	require.Error(err)
+	b1.Release()
+	b2.Release()
+
+	require.Zero(GetObjectsInUse())
+}
+
+func TestCompare(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(`
+name: string
+qty: int32
+price: float64
+active: bool
+ids..: int64
+`)
+	require.NoError(err)
+	newBuf := func(name string, qty int32, price float64, ids []int64) *Buffer {
+		b := NewBuffer(s)
+		b.Set("name", name)
+		b.Set("qty", qty)
+		b.Set("price", price)
+		b.Set("ids", ids)
+		return b
+	}
+	b1 := newBuf("a", 1, 1.5, []int64{1, 2})
+	b2 := newBuf("b", 1, 0.5, []int64{1, 2, 3})
+
+	require.Equal(-1, compare(t, b1, b2))
+	require.Equal(1, compare(t, b2, b1))
+	require.Equal(0, compare(t, b1, b2, "qty"))
+	require.Equal(1, compare(t, b1, b2, "qty", "price"))
+	require.Equal(-1, compare(t, b1, b2, "qty", "ids"))
+	require.Equal(0, compare(t, b1, b2, "unknown"))
+
+	// unset is less than set
+	b1.Set("active", false)
+	require.Equal(1, compare(t, b1, b2, "active"))
+	b2.Set("active", true)
+	require.Equal(-1, compare(t, b1, b2, "active"))
+
+	b1.Release()
+	b2.Release()
+	require.Zero(GetObjectsInUse())
+}
+
+func compare(t *testing.T, b1, b2 *Buffer, fields ...string) int {
+	res, err := b1.Compare(b2, fields...)
+	require.NoError(t, err)
+	return res
+}
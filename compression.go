@@ -0,0 +1,274 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+)
+
+// Envelope is bytes of a Buffer compressed by a Codec:
+//
+//	magic "DYNZ" | codec ID: byte | uncompressed size: uint32 little-endian | compressed bytes
+//
+// Uncompressed size 0 -> empty Buffer, compressed bytes are empty
+
+// EnvelopeMagic starts the envelope
+const EnvelopeMagic = "DYNZ"
+
+// EnvelopeHeaderSize is the size of the envelope header
+const EnvelopeHeaderSize = len(EnvelopeMagic) + 1 + 4
+
+var (
+	// ErrMalformedEnvelope is returned if bytes are not an envelope or could not be decompressed
+	ErrMalformedEnvelope = errors.New("malformed envelope")
+	// ErrUnknownCodec is returned if the codec is not registered
+	ErrUnknownCodec = errors.New("unknown codec")
+)
+
+// CodecID identifies Codec in the envelope
+type CodecID byte
+
+const (
+	// CodecFlate is compress/flate with the default compression level
+	CodecFlate CodecID = 1
+	// CodecGzip is compress/gzip with the default compression level
+	CodecGzip CodecID = 2
+)
+
+// Codec compresses bytes of Buffers. Must be goroutine-safe
+type Codec interface {
+	ID() CodecID
+	// Compress appends compressed `src` to `dst` and returns the extended slice
+	Compress(dst, src []byte) ([]byte, error)
+	// Decompress fills `dst` by decompressed `src`. Error is returned if the uncompressed size is not len(dst)
+	Decompress(dst, src []byte) error
+}
+
+var (
+	codecs     = map[CodecID]Codec{}
+	codecsLock sync.RWMutex
+)
+
+func init() {
+	for _, c := range []Codec{
+		newStreamCodec(CodecFlate,
+			func(w io.Writer) (io.WriteCloser, error) { return flate.NewWriter(w, flate.DefaultCompression) },
+			func(w io.WriteCloser, dst io.Writer) { w.(*flate.Writer).Reset(dst) },
+			func(r io.Reader) (io.ReadCloser, error) { return flate.NewReader(r), nil },
+			func(r io.ReadCloser, src io.Reader) error { return r.(flate.Resetter).Reset(src, nil) },
+		),
+		newStreamCodec(CodecGzip,
+			func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriterLevel(w, gzip.DefaultCompression) },
+			func(w io.WriteCloser, dst io.Writer) { w.(*gzip.Writer).Reset(dst) },
+			func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+			func(r io.ReadCloser, src io.Reader) error { return r.(*gzip.Reader).Reset(src) },
+		),
+	} {
+		if err := RegisterCodec(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterCodec makes the codec available for ToBytesCompressed() and ReadBufferCompressed()
+// Error is returned if a codec with the same ID is registered already
+func RegisterCodec(c Codec) error {
+	codecsLock.Lock()
+	defer codecsLock.Unlock()
+	if _, ok := codecs[c.ID()]; ok {
+		return fmt.Errorf("codec %d is registered already", c.ID())
+	}
+	codecs[c.ID()] = c
+	return nil
+}
+
+// GetCodec returns the registered codec, false if there is no such codec
+func GetCodec(id CodecID) (Codec, bool) {
+	codecsLock.RLock()
+	defer codecsLock.RUnlock()
+	c, ok := codecs[id]
+	return c, ok
+}
+
+// ToBytesCompressed returns the envelope of ToBytes() result compressed by the codec. The result is owned by the caller
+// Error wraps ErrUnknownCodec if the codec is not registered
+func (b *Buffer) ToBytesCompressed(codec CodecID) ([]byte, error) {
+	bytes, err := b.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+	return CompressBytes(bytes, codec)
+}
+
+// CompressBytes returns the envelope of bytes compressed by the codec
+// Error wraps ErrUnknownCodec if the codec is not registered
+func CompressBytes(bytes []byte, codec CodecID) ([]byte, error) {
+	c, ok := GetCodec(codec)
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownCodec, codec)
+	}
+	if uint64(len(bytes)) > math.MaxUint32 {
+		return nil, fmt.Errorf("%w: %d bytes", ErrRecordTooLarge, len(bytes))
+	}
+	res := make([]byte, EnvelopeHeaderSize, EnvelopeHeaderSize+len(bytes)/2)
+	copy(res, EnvelopeMagic)
+	res[len(EnvelopeMagic)] = byte(codec)
+	binary.LittleEndian.PutUint32(res[len(EnvelopeMagic)+1:], uint32(len(bytes)))
+	if len(bytes) == 0 {
+		return res, nil
+	}
+	return c.Compress(res, bytes)
+}
+
+// IsCompressed returns true if bytes start with the envelope header
+func IsCompressed(bytes []byte) bool {
+	return len(bytes) >= EnvelopeHeaderSize && string(bytes[:len(EnvelopeMagic)]) == EnvelopeMagic
+}
+
+// ReadBufferCompressed creates Buffer from the envelope made by ToBytesCompressed() and makes checks specified by options
+// Bytes are decompressed into a pooled buffer which is returned to the pool on the Buffer release, so the Buffer must be released
+// Note: strings, byte arrays and array slices got from the Buffer (GetString(), Get() etc) refer to the pooled bytes and are
+// overwritten by a next read after the release. Copy them to keep or use WithUnpooledBytes()
+// The uncompressed size is limited by WithMaxDecompressedSize(). Returns nil Buffer on error
+// Error wraps ErrMalformedEnvelope, ErrUnknownCodec, ErrRecordTooLarge or an error of a check
+func ReadBufferCompressed(bytes []byte, scheme *Scheme, opts ...ReadOption) (*Buffer, error) {
+	ro := newReadOptions(opts)
+	if !IsCompressed(bytes) {
+		return nil, fmt.Errorf("%w: no envelope header", ErrMalformedEnvelope)
+	}
+	codec := CodecID(bytes[len(EnvelopeMagic)])
+	c, ok := GetCodec(codec)
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownCodec, codec)
+	}
+	size := binary.LittleEndian.Uint32(bytes[len(EnvelopeMagic)+1:])
+	if uint64(size) > uint64(ro.maxDecompressedSize) {
+		return nil, fmt.Errorf("%w: %d bytes", ErrRecordTooLarge, size)
+	}
+	compressed := bytes[EnvelopeHeaderSize:]
+	var db *decompressedBytes
+	var decompressed []byte
+	if ro.unpooledBytes {
+		decompressed = make([]byte, size)
+	} else {
+		db = getDecompressedBytes(int(size))
+		decompressed = db.bytes
+	}
+	release := func() {
+		if db != nil {
+			db.Release()
+		}
+	}
+	if size > 0 {
+		if err := c.Decompress(decompressed, compressed); err != nil {
+			release()
+			return nil, fmt.Errorf("%w: %w", ErrMalformedEnvelope, err)
+		}
+	} else if len(compressed) > 0 {
+		release()
+		return nil, fmt.Errorf("%w: %d bytes after empty content", ErrMalformedEnvelope, len(compressed))
+	}
+	b, err := ro.read(decompressed, scheme)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	if db != nil {
+		b.toRelease = append(b.toRelease, db)
+	}
+	return b, nil
+}
+
+// streamCodec is Codec based on io.Writer and io.Reader of a compress package. Writers and readers are pooled
+type streamCodec struct {
+	id          CodecID
+	writers     sync.Pool
+	readers     sync.Pool
+	newWriter   func(w io.Writer) (io.WriteCloser, error)
+	resetWriter func(w io.WriteCloser, dst io.Writer)
+	newReader   func(r io.Reader) (io.ReadCloser, error)
+	resetReader func(r io.ReadCloser, src io.Reader) error
+}
+
+func newStreamCodec(id CodecID, newWriter func(w io.Writer) (io.WriteCloser, error), resetWriter func(w io.WriteCloser, dst io.Writer),
+	newReader func(r io.Reader) (io.ReadCloser, error), resetReader func(r io.ReadCloser, src io.Reader) error) *streamCodec {
+	return &streamCodec{id: id, newWriter: newWriter, resetWriter: resetWriter, newReader: newReader, resetReader: resetReader}
+}
+
+func (sc *streamCodec) ID() CodecID {
+	return sc.id
+}
+
+// appendWriter appends written bytes to the slice
+type appendWriter struct {
+	bytes []byte
+}
+
+func (aw *appendWriter) Write(p []byte) (int, error) {
+	aw.bytes = append(aw.bytes, p...)
+	return len(p), nil
+}
+
+func (sc *streamCodec) Compress(dst, src []byte) ([]byte, error) {
+	aw := &appendWriter{bytes: dst}
+	var w io.WriteCloser
+	if pooled := sc.writers.Get(); pooled != nil {
+		w = pooled.(io.WriteCloser)
+		sc.resetWriter(w, aw)
+	} else {
+		var err error
+		if w, err = sc.newWriter(aw); err != nil {
+			return nil, err
+		}
+	}
+	defer sc.writers.Put(w)
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return aw.bytes, nil
+}
+
+func (sc *streamCodec) Decompress(dst, src []byte) error {
+	br := bytes.NewReader(src)
+	var r io.ReadCloser
+	if pooled := sc.readers.Get(); pooled != nil {
+		r = pooled.(io.ReadCloser)
+		if err := sc.resetReader(r, br); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if r, err = sc.newReader(br); err != nil {
+			return err
+		}
+	}
+	defer sc.readers.Put(r)
+	if _, err := io.ReadFull(r, dst); err != nil {
+		return fmt.Errorf("%d bytes expected: %w", len(dst), err)
+	}
+	// checks the end of the stream and checksums
+	var extra [1]byte
+	if n, err := r.Read(extra[:]); n > 0 || err != io.EOF {
+		if err == nil || err == io.EOF {
+			err = errors.New("uncompressed size exceeds the declared one")
+		}
+		return err
+	}
+	return r.Close()
+}
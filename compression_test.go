@@ -0,0 +1,231 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+// identityCodec stores bytes as is
+type identityCodec struct{}
+
+func (identityCodec) ID() CodecID {
+	return 200
+}
+
+func (identityCodec) Compress(dst, src []byte) ([]byte, error) {
+	return append(dst, src...), nil
+}
+
+func (identityCodec) Decompress(dst, src []byte) error {
+	if len(dst) != len(src) {
+		return errors.New("size mismatch")
+	}
+	copy(dst, src)
+	return nil
+}
+
+func getCompressibleBuffer(t *testing.T) (*Buffer, []byte) {
+	s, err := YamlToScheme(`
+name: string
+quantity: int32
+notes..: string
+`)
+	require.NoError(t, err)
+	b := NewBuffer(s)
+	b.Set("name", strings.Repeat("cola ", 50))
+	b.Set("quantity", int32(42))
+	b.Set("notes", []string{strings.Repeat("a", 100), strings.Repeat("b", 100)})
+	bytes, err := b.ToBytes()
+	require.NoError(t, err)
+	return b, copyBytes(bytes)
+}
+
+func TestCompression(t *testing.T) {
+	require := require.New(t)
+	b, plain := getCompressibleBuffer(t)
+
+	for _, codec := range []CodecID{CodecFlate, CodecGzip} {
+		compressed, err := b.ToBytesCompressed(codec)
+		require.NoError(err)
+		require.True(IsCompressed(compressed))
+		require.False(IsCompressed(plain))
+		require.Less(len(compressed), len(plain))
+		require.Equal(EnvelopeMagic, string(compressed[:4]))
+		require.Equal(byte(codec), compressed[4])
+		require.Equal(uint32(len(plain)), binary.LittleEndian.Uint32(compressed[5:EnvelopeHeaderSize]))
+
+		fromBytes, err := CompressBytes(plain, codec)
+		require.NoError(err)
+		require.Equal(compressed, fromBytes)
+
+		// decompressed bytes are pooled and released with the Buffer
+		for i := 0; i < 3; i++ {
+			read, err := ReadBufferCompressed(compressed, b.Scheme)
+			require.NoError(err)
+			require.Equal(b.ToJSON(), read.ToJSON())
+			readBytes, err := read.ToBytes()
+			require.NoError(err)
+			require.Equal(plain, readBytes)
+			require.NotZero(GetObjectsInUse())
+			read.Release()
+		}
+	}
+
+	// empty Buffer
+	empty := NewBuffer(b.Scheme)
+	compressed, err := empty.ToBytesCompressed(CodecGzip)
+	require.NoError(err)
+	require.Len(compressed, EnvelopeHeaderSize)
+	empty.Release()
+	read, err := ReadBufferCompressed(compressed, b.Scheme)
+	require.NoError(err)
+	require.False(read.HasValue("name"))
+	read.Release()
+
+	// strings refer to the pooled bytes, so they are invalid after the release
+	compressed, err = CompressBytes(plain, CodecGzip)
+	require.NoError(err)
+	read, err = ReadBufferCompressed(compressed, b.Scheme)
+	require.NoError(err)
+	name, ok := read.GetString("name")
+	require.True(ok)
+	pooled := read.toRelease[len(read.toRelease)-1].(*decompressedBytes).bytes
+	require.Same(unsafe.StringData(name), &pooled[strings.Index(string(pooled), name)])
+	read.Release()
+
+	// unpooled bytes -> strings are valid after the release
+	inUse := GetObjectsInUse()
+	read, err = ReadBufferCompressed(compressed, b.Scheme, WithUnpooledBytes())
+	require.NoError(err)
+	require.Equal(inUse+1, GetObjectsInUse()) // the Buffer only
+	name, ok = read.GetString("name")
+	require.True(ok)
+	read.Release()
+	for i := 0; i < 3; i++ {
+		other, err := ReadBufferCompressed(compressed, b.Scheme)
+		require.NoError(err)
+		other.Release()
+	}
+	require.Equal(strings.Repeat("cola ", 50), name)
+
+	// options are applied to decompressed bytes
+	mandatory, err := YamlToScheme("name: string\nquantity: int32\nnotes..: string\nCustomer: string")
+	require.NoError(err)
+	compressed, err = CompressBytes(plain, CodecFlate)
+	require.NoError(err)
+	_, err = ReadBufferCompressed(compressed, mandatory, WithMandatoryCheck())
+	require.Error(err)
+
+	b.Release()
+	require.Zero(GetObjectsInUse())
+}
+
+func TestCompressionErrors(t *testing.T) {
+	require := require.New(t)
+	b, plain := getCompressibleBuffer(t)
+
+	_, err := b.ToBytesCompressed(CodecID(199))
+	require.ErrorIs(err, ErrUnknownCodec)
+
+	compressed, err := b.ToBytesCompressed(CodecGzip)
+	require.NoError(err)
+
+	// not an envelope
+	_, err = ReadBufferCompressed(plain, b.Scheme)
+	require.ErrorIs(err, ErrMalformedEnvelope)
+	_, err = ReadBufferCompressed(compressed[:EnvelopeHeaderSize-1], b.Scheme)
+	require.ErrorIs(err, ErrMalformedEnvelope)
+
+	// unknown codec
+	wrongCodec := copyBytes(compressed)
+	wrongCodec[4] = 199
+	_, err = ReadBufferCompressed(wrongCodec, b.Scheme)
+	require.ErrorIs(err, ErrUnknownCodec)
+
+	// corrupted payload
+	corrupted := copyBytes(compressed)
+	corrupted[len(corrupted)-10] ^= 0xFF
+	_, err = ReadBufferCompressed(corrupted, b.Scheme)
+	require.ErrorIs(err, ErrMalformedEnvelope)
+	_, err = ReadBufferCompressed(compressed[:len(compressed)-4], b.Scheme)
+	require.ErrorIs(err, ErrMalformedEnvelope)
+
+	// declared size mismatch
+	for _, size := range []int{len(plain) - 1, len(plain) + 1, 0} {
+		wrongSize := copyBytes(compressed)
+		binary.LittleEndian.PutUint32(wrongSize[5:], uint32(size))
+		_, err = ReadBufferCompressed(wrongSize, b.Scheme)
+		require.ErrorIs(err, ErrMalformedEnvelope, size)
+	}
+
+	// size limit
+	_, err = ReadBufferCompressed(compressed, b.Scheme, WithMaxDecompressedSize(len(plain)-1))
+	require.ErrorIs(err, ErrRecordTooLarge)
+	read, err := ReadBufferCompressed(compressed, b.Scheme, WithMaxDecompressedSize(len(plain)))
+	require.NoError(err)
+	read.Release()
+	huge := copyBytes(compressed)
+	binary.LittleEndian.PutUint32(huge[5:], DefaultMaxRecordSize+1)
+	_, err = ReadBufferCompressed(huge, b.Scheme)
+	require.ErrorIs(err, ErrRecordTooLarge)
+
+	b.Release()
+	require.Zero(GetObjectsInUse())
+}
+
+func TestRegisterCodec(t *testing.T) {
+	require := require.New(t)
+	require.Error(RegisterCodec(identityCodec{}.withID(CodecGzip)))
+
+	_, ok := GetCodec(identityCodec{}.ID())
+	require.False(ok)
+	require.NoError(RegisterCodec(identityCodec{}))
+	defer func() {
+		codecsLock.Lock()
+		delete(codecs, identityCodec{}.ID())
+		codecsLock.Unlock()
+	}()
+	require.Error(RegisterCodec(identityCodec{}))
+	c, ok := GetCodec(identityCodec{}.ID())
+	require.True(ok)
+	require.Equal(identityCodec{}, c)
+
+	b, plain := getCompressibleBuffer(t)
+	compressed, err := b.ToBytesCompressed(identityCodec{}.ID())
+	require.NoError(err)
+	require.True(bytes.HasSuffix(compressed, plain))
+	read, err := ReadBufferCompressed(compressed, b.Scheme)
+	require.NoError(err)
+	require.Equal(b.ToJSON(), read.ToJSON())
+	read.Release()
+	b.Release()
+
+	require.Zero(GetObjectsInUse())
+}
+
+// codecWithID is a codec which reports another ID
+type codecWithID struct {
+	Codec
+	id CodecID
+}
+
+func (c codecWithID) ID() CodecID {
+	return c.id
+}
+
+func (ic identityCodec) withID(id CodecID) Codec {
+	return codecWithID{Codec: ic, id: id}
+}
@@ -0,0 +1,272 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// ChangeKind describes kind of a Change
+type ChangeKind int
+
+const (
+	// ChangeKindSet value is set or changed
+	ChangeKindSet ChangeKind = iota
+	// ChangeKindUnset value is unset
+	ChangeKindUnset
+	// ChangeKindAppended array element is appended
+	ChangeKindAppended
+	// ChangeKindRemoved array element is removed from the end of the array
+	ChangeKindRemoved
+)
+
+var changeKindNames = map[ChangeKind]string{
+	ChangeKindSet:      "set",
+	ChangeKindUnset:    "unset",
+	ChangeKindAppended: "appended",
+	ChangeKindRemoved:  "removed",
+}
+
+func (k ChangeKind) String() string {
+	if name, ok := changeKindNames[k]; ok {
+		return name
+	}
+	return "ChangeKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// MarshalText renders ChangeKind as its name in JSON
+func (k ChangeKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Change describes a single difference between two Buffers. See Diff()
+// Path has the same syntax as for GetPath(), e.g. "lines[1].article.id"
+// Old is nil for set from unset and for appended element, New is nil for unset and removed element
+// Nested objects of appended or removed array elements are represented as map[string]interface{}, see toJSONMap(nil)
+type Change struct {
+	Path string      `json:"path"`
+	Kind ChangeKind  `json:"kind"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// Diff returns changes which turn `oldBuf` into `newBuf`. Pending modifications are considered
+// Fields are matched by name, unset and empty values are equal, floats are compared by value. See Equal()
+// Nested objects and arrays are compared recursively: changed array elements are reported by index, extra elements are reported as appended or removed
+// Byte arrays are compared as single values. Field types differ -> the whole value is reported as set
+// Returns error if pending modifications of any Buffer could not be encoded
+// Note: Old and New values could refer to the underlying bytes of the Buffers so they must not be used after the Buffers are released or modified
+func Diff(oldBuf, newBuf *Buffer) ([]Change, error) {
+	oldView, oldRelease, err := oldBuf.committedView()
+	if err != nil {
+		return nil, err
+	}
+	defer oldRelease()
+	newView, newRelease, err := newBuf.committedView()
+	if err != nil {
+		return nil, err
+	}
+	defer newRelease()
+	changes := []Change{}
+	diffBuffers(&changes, "", oldView, newView)
+	return changes, nil
+}
+
+// ChangesToJSON renders changes as JSON array, e.g. for an audit log
+func ChangesToJSON(changes []Change) ([]byte, error) {
+	return json.Marshal(changes)
+}
+
+func diffBuffers(changes *[]Change, prefix string, a, b *Buffer) {
+	if a != nil {
+		for _, f := range a.Scheme.Fields {
+			diffFields(changes, prefix, a, b, f.Name)
+		}
+	}
+	if b != nil {
+		for _, f := range b.Scheme.Fields {
+			if a != nil {
+				if _, ok := a.Scheme.FieldsMap[f.Name]; ok {
+					continue
+				}
+			}
+			diffFields(changes, prefix, a, b, f.Name)
+		}
+	}
+}
+
+func diffFields(changes *[]Change, prefix string, a, b *Buffer, name string) {
+	fa, oa := a.storedField(name)
+	fb, ob := b.storedField(name)
+	if oa == 0 && ob == 0 {
+		return
+	}
+	path := prefix + name
+	if oa != 0 && ob != 0 && (fa.Ft != fb.Ft || fa.IsArray != fb.IsArray) {
+		*changes = append(*changes, Change{Path: path, Kind: ChangeKindSet, Old: a.plainValue(fa, oa), New: b.plainValue(fb, ob)})
+		return
+	}
+	f := fa
+	if f == nil || oa == 0 {
+		f = fb
+	}
+	switch {
+	case f.Ft == FieldTypeObject && !f.IsArray:
+		nestedA := a.readNestedOrNil(fa, oa)
+		nestedB := b.readNestedOrNil(fb, ob)
+		diffBuffers(changes, path+".", nestedA, nestedB)
+		if nestedA != nil {
+			nestedA.Release()
+		}
+		if nestedB != nil {
+			nestedB.Release()
+		}
+	case f.Ft == FieldTypeObject:
+		diffObjectArrays(changes, path, a, b, fa, fb, oa, ob)
+	case f.IsArray && f.Ft != FieldTypeByte:
+		diffArrays(changes, path, a, b, fa, fb, oa, ob)
+	case oa == 0:
+		*changes = append(*changes, Change{Path: path, Kind: ChangeKindSet, New: b.plainValue(fb, ob)})
+	case ob == 0:
+		*changes = append(*changes, Change{Path: path, Kind: ChangeKindUnset, Old: a.plainValue(fa, oa)})
+	case compareFields(a, b, name) != 0:
+		*changes = append(*changes, Change{Path: path, Kind: ChangeKindSet, Old: a.plainValue(fa, oa), New: b.plainValue(fb, ob)})
+	}
+}
+
+func diffArrays(changes *[]Change, path string, a, b *Buffer, fa, fb *Field, oa, ob flatbuffers.UOffsetT) {
+	var arrA, arrB interface{}
+	if oa != 0 {
+		arrA = a.getAllValues(oa, fa)
+	}
+	if ob != 0 {
+		arrB = b.getAllValues(ob, fb)
+	}
+	f := fa
+	if f == nil || oa == 0 {
+		f = fb
+	}
+	switch f.Ft {
+	case FieldTypeInt16:
+		diffSlices(changes, path, asSlice[int16](arrA), asSlice[int16](arrB))
+	case FieldTypeInt32:
+		diffSlices(changes, path, asSlice[int32](arrA), asSlice[int32](arrB))
+	case FieldTypeInt64:
+		diffSlices(changes, path, asSlice[int64](arrA), asSlice[int64](arrB))
+	case FieldTypeFloat32:
+		diffSlices(changes, path, asSlice[float32](arrA), asSlice[float32](arrB))
+	case FieldTypeFloat64:
+		diffSlices(changes, path, asSlice[float64](arrA), asSlice[float64](arrB))
+	case FieldTypeBool:
+		diffSlices(changes, path, asSlice[bool](arrA), asSlice[bool](arrB))
+	case FieldTypeString:
+		diffSlices(changes, path, asSlice[string](arrA), asSlice[string](arrB))
+	}
+}
+
+func asSlice[T any](arr interface{}) []T {
+	res, _ := arr.([]T)
+	return res
+}
+
+func diffSlices[T comparable](changes *[]Change, path string, a, b []T) {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		elemPath := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case i >= len(b):
+			*changes = append(*changes, Change{Path: elemPath, Kind: ChangeKindRemoved, Old: a[i]})
+		case i >= len(a):
+			*changes = append(*changes, Change{Path: elemPath, Kind: ChangeKindAppended, New: b[i]})
+		case !equalValues(a[i], b[i]):
+			*changes = append(*changes, Change{Path: elemPath, Kind: ChangeKindSet, Old: a[i], New: b[i]})
+		}
+	}
+}
+
+// equalValues returns true if values are equal. NaN equals to NaN
+func equalValues[T comparable](a, b T) bool {
+	if a == b {
+		return true
+	}
+	switch va := any(a).(type) {
+	case float32:
+		return cmp.Compare(va, any(b).(float32)) == 0
+	case float64:
+		return cmp.Compare(va, any(b).(float64)) == 0
+	}
+	return false
+}
+
+func diffObjectArrays(changes *[]Change, path string, a, b *Buffer, fa, fb *Field, oa, ob flatbuffers.UOffsetT) {
+	la, lb := 0, 0
+	var elemA, elemB *Buffer
+	if oa != 0 {
+		la = a.tab.VectorLen(oa - a.tab.Pos)
+		elemA = a.readNested(fa, 0)
+		defer elemA.Release()
+	}
+	if ob != 0 {
+		lb = b.tab.VectorLen(ob - b.tab.Pos)
+		elemB = b.readNested(fb, 0)
+		defer elemB.Release()
+	}
+	for i := 0; i < la || i < lb; i++ {
+		elemPath := fmt.Sprintf("%s[%d]", path, i)
+		if i < la {
+			elemA.releaseFieldsToBytes()
+			elemA.tab.Pos = a.tab.Indirect(a.tab.Vector(oa-a.tab.Pos) + flatbuffers.UOffsetT(la-1-i)*flatbuffers.SizeUOffsetT)
+		}
+		if i < lb {
+			elemB.releaseFieldsToBytes()
+			elemB.tab.Pos = b.tab.Indirect(b.tab.Vector(ob-b.tab.Pos) + flatbuffers.UOffsetT(lb-1-i)*flatbuffers.SizeUOffsetT)
+		}
+		switch {
+		case i >= lb:
+			*changes = append(*changes, Change{Path: elemPath, Kind: ChangeKindRemoved, Old: elemA.toJSONMap(nil)})
+		case i >= la:
+			*changes = append(*changes, Change{Path: elemPath, Kind: ChangeKindAppended, New: elemB.toJSONMap(nil)})
+		default:
+			diffBuffers(changes, elemPath+".", elemA, elemB)
+		}
+	}
+}
+
+// readNestedOrNil returns Buffer to read stored nested object, nil if the object is unset. Result must be released by the caller
+func (b *Buffer) readNestedOrNil(f *Field, uOffsetT flatbuffers.UOffsetT) *Buffer {
+	if uOffsetT == 0 {
+		return nil
+	}
+	return b.readNested(f, b.tab.Indirect(uOffsetT))
+}
+
+// plainValue returns stored value. Nested objects are represented as map[string]interface{}, arrays of nested objects as []interface{} of maps
+func (b *Buffer) plainValue(f *Field, uOffsetT flatbuffers.UOffsetT) interface{} {
+	if f.Ft != FieldTypeObject {
+		return b.getByUOffsetT(f, uOffsetT)
+	}
+	if !f.IsArray {
+		nested := b.readNested(f, b.tab.Indirect(uOffsetT))
+		defer nested.Release()
+		return nested.toJSONMap(nil)
+	}
+	l := b.tab.VectorLen(uOffsetT - b.tab.Pos)
+	res := make([]interface{}, 0, l)
+	elem := b.readNested(f, 0)
+	defer elem.Release()
+	for i := 0; i < l; i++ {
+		elem.releaseFieldsToBytes()
+		elem.tab.Pos = b.tab.Indirect(b.tab.Vector(uOffsetT-b.tab.Pos) + flatbuffers.UOffsetT(l-1-i)*flatbuffers.SizeUOffsetT)
+		res = append(res, elem.toJSONMap(nil))
+	}
+	return res
+}
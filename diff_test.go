@@ -0,0 +1,91 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(orderSchemeYaml)
+	require.NoError(err)
+	orderBytes := getOrderBytes(t, s)
+
+	bOld := ReadBuffer(orderBytes, s)
+	bNew := ReadBuffer(orderBytes, s)
+	require.Empty(diff(t, bOld, bNew))
+
+	bNew.Set("name", "order2")
+	bNew.Set("article", nil)
+	bNew.Append("tags", []string{"new"})
+	bNew.ReplaceAt("tags", 0, "regular")
+	lines := bNew.GetMutableObjectArray("lines")
+	lines.At(0).Set("qty", int32(5))
+	lines.At(0).Get("article").(*Buffer).Set("name", "art11")
+	lines.Remove(1)
+
+	changes := diff(t, bOld, bNew)
+	require.Equal([]Change{
+		{Path: "name", Kind: ChangeKindSet, Old: "order", New: "order2"},
+		{Path: "tags[0]", Kind: ChangeKindSet, Old: "vip", New: "regular"},
+		{Path: "tags[2]", Kind: ChangeKindAppended, New: "new"},
+		{Path: "article.id", Kind: ChangeKindUnset, Old: int64(1)},
+		{Path: "article.name", Kind: ChangeKindUnset, Old: "cola"},
+		{Path: "lines[0].qty", Kind: ChangeKindSet, Old: int32(1), New: int32(5)},
+		{Path: "lines[0].article.name", Kind: ChangeKindSet, Old: "art10", New: "art11"},
+		{Path: "lines[1]", Kind: ChangeKindRemoved, Old: map[string]interface{}{
+			"qty": int32(2), "price": 2.5, "article": map[string]interface{}{"id": int64(20), "name": "art20"},
+		}},
+	}, changes)
+
+	json, err := ChangesToJSON(changes[:4])
+	require.NoError(err)
+	require.JSONEq(`[
+		{"path":"name","kind":"set","old":"order","new":"order2"},
+		{"path":"tags[0]","kind":"set","old":"vip","new":"regular"},
+		{"path":"tags[2]","kind":"appended","new":"new"},
+		{"path":"article.id","kind":"unset","old":1}
+	]`, string(json))
+
+	// reverse
+	changes = diff(t, bNew, bOld)
+	require.Contains(changes, Change{Path: "tags[2]", Kind: ChangeKindRemoved, Old: "new"})
+	require.Contains(changes, Change{Path: "article.id", Kind: ChangeKindSet, New: int64(1)})
+	require.Contains(changes, Change{Path: "lines[1]", Kind: ChangeKindAppended, New: map[string]interface{}{
+		"qty": int32(2), "price": 2.5, "article": map[string]interface{}{"id": int64(20), "name": "art20"},
+	}})
+
+	// from and to empty
+	bEmpty := NewBuffer(s)
+	require.Len(diff(t, bEmpty, bOld), 7)
+	require.Len(diff(t, nil, bOld), 7)
+	require.Equal(Change{Path: "name", Kind: ChangeKindUnset, Old: "order"}, diff(t, bOld, bEmpty)[0])
+
+	// field type differs
+	sOther, err := YamlToScheme("name: int32")
+	require.NoError(err)
+	bOther := NewBuffer(sOther)
+	bOther.Set("name", int32(1))
+	require.NoError(bOther.CommitChanges())
+	require.Contains(diff(t, bOld, bOther), Change{Path: "name", Kind: ChangeKindSet, Old: "order", New: int32(1)})
+
+	bOther.Release()
+	bEmpty.Release()
+	bOld.Release()
+	bNew.Release()
+	require.Zero(GetObjectsInUse())
+}
+
+func diff(t *testing.T, oldBuf, newBuf *Buffer) []Change {
+	res, err := Diff(oldBuf, newBuf)
+	require.NoError(t, err)
+	return res
+}
@@ -68,12 +68,19 @@ type Buffer struct {
 	owner         *Buffer
 	builder       *flatbuffers.Builder
 	toRelease     []IRelease
+	redaction     *RedactionPolicy
 }
 
 type IRelease interface {
 	Release()
 }
 
+// IBytes is an owned result of ToBytesPooled(). Bytes() are valid until Release()
+type IBytes interface {
+	Bytes() []byte
+	Release()
+}
+
 type Field struct {
 	Name        string
 	Ft          FieldType
@@ -82,6 +89,8 @@ type Field struct {
 	FieldScheme *Scheme // != nil for FieldTypeObject only
 	ownerScheme *Scheme
 	IsArray     bool
+	Sensitivity string // sensitivity label, e.g. `card` or `pii`. Empty -> the field is not sensitive. See RedactionPolicy
+	transformer FieldTransformer
 }
 
 type fieldToBytes struct {
@@ -89,9 +98,20 @@ type fieldToBytes struct {
 	value        interface{}
 	isAppend     bool
 	isValueEmpty bool // value is empty object, array or string -> true. Used in [Buffer.ToBytesNilled]
+
+	// value provided by a typed setter (SetInt64(), SetString(), SetInt64Array() etc) is stored unboxed to avoid allocations
+	// typedFt != FieldTypeUnspecified -> typed value is used, value is nil
+	typedFt      FieldType
+	typedIsArray bool
+	typedScalar  uint64         // bits of int16, int32, int64, float32, float64, bool or byte
+	typedArr     unsafe.Pointer // string data or the first element of an array
+	typedArrLen  int
+
+	arrayOps []arrayOp // pending operations made by RemoveAt(), InsertAt(), ReplaceAt(), RemoveValue(), Truncate()
 }
 
 func (m *fieldToBytes) Release() {
+	m.resetArrayOps()
 	if !m.hasValue {
 		return
 	}
@@ -109,10 +129,76 @@ func (m *fieldToBytes) Release() {
 	m.isAppend = false
 	m.hasValue = false
 	m.isValueEmpty = false
+	m.resetTyped()
+}
+
+func (m *fieldToBytes) resetTyped() {
+	m.typedFt = FieldTypeUnspecified
+	m.typedIsArray = false
+	m.typedScalar = 0
+	m.typedArr = nil
+	m.typedArrLen = 0
+}
+
+func (m *fieldToBytes) resetArrayOps() {
+	for i := range m.arrayOps {
+		m.arrayOps[i] = arrayOp{}
+	}
+	m.arrayOps = m.arrayOps[:0]
+}
+
+// isNil returns true if the field is set to nil, i.e. unset
+func (m *fieldToBytes) isNil() bool {
+	return m.typedFt == FieldTypeUnspecified && m.value == nil
+}
+
+// getValue returns the value provided to Set() or boxed value provided to a typed setter
+func (m *fieldToBytes) getValue() interface{} {
+	if m.typedFt == FieldTypeUnspecified {
+		return m.value
+	}
+	if m.typedIsArray {
+		switch m.typedFt {
+		case FieldTypeInt16:
+			return unsafe.Slice((*int16)(m.typedArr), m.typedArrLen)
+		case FieldTypeInt32:
+			return unsafe.Slice((*int32)(m.typedArr), m.typedArrLen)
+		case FieldTypeInt64:
+			return unsafe.Slice((*int64)(m.typedArr), m.typedArrLen)
+		case FieldTypeFloat32:
+			return unsafe.Slice((*float32)(m.typedArr), m.typedArrLen)
+		case FieldTypeFloat64:
+			return unsafe.Slice((*float64)(m.typedArr), m.typedArrLen)
+		case FieldTypeBool:
+			return unsafe.Slice((*bool)(m.typedArr), m.typedArrLen)
+		case FieldTypeByte:
+			return unsafe.Slice((*byte)(m.typedArr), m.typedArrLen)
+		default: // string
+			return unsafe.Slice((*string)(m.typedArr), m.typedArrLen)
+		}
+	}
+	switch m.typedFt {
+	case FieldTypeInt16:
+		return int16(m.typedScalar)
+	case FieldTypeInt32:
+		return int32(m.typedScalar)
+	case FieldTypeInt64:
+		return int64(m.typedScalar)
+	case FieldTypeFloat32:
+		return math.Float32frombits(uint32(m.typedScalar))
+	case FieldTypeFloat64:
+		return math.Float64frombits(m.typedScalar)
+	case FieldTypeBool:
+		return m.typedScalar != 0
+	case FieldTypeByte:
+		return byte(m.typedScalar)
+	default: // string
+		return unsafe.String((*byte)(m.typedArr), m.typedArrLen)
+	}
 }
 
 // ObjectArray used to iterate over array of nested objects
-// ObjectArray.Buffer should be used for reading only
+// ObjectArray.Buffer should be used for reading only. Use Buffer.GetMutableObjectArray() to modify array elements
 type ObjectArray struct {
 	Buffer     *Buffer
 	Len        int
@@ -127,15 +213,49 @@ func (oa *ObjectArray) Next() bool {
 	if oa.curElem >= oa.Len {
 		return false
 	}
+	// nested objects of the previous element are cached on Get() and must not be considered for the next element
+	// they could be still in use so they are released on the array release
+	oa.Buffer.releaseFieldsToBytesLater()
 	oa.Buffer.tab.Pos = oa.Buffer.tab.Indirect(oa.start + flatbuffers.UOffsetT(oa.Len-1-oa.curElem)*flatbuffers.SizeUOffsetT)
 	return true
 }
 
+// At makes the element by index current and returns .Buffer. Next() proceeds to the element after it. Panics if index is out of range
+func (oa *ObjectArray) At(idx int) *Buffer {
+	if idx < 0 || idx >= oa.Len {
+		panic(fmt.Sprintf("index out of range: %d of %d", idx, oa.Len))
+	}
+	oa.curElem = idx - 1
+	oa.Next()
+	return oa.Buffer
+}
+
 // Value returns *dynobuffers.Buffer instance as current element
 func (oa *ObjectArray) Value() interface{} {
 	return oa.Buffer
 }
 
+// Detach returns the current element as an independent Buffer with its own bytes. See CloneAt()
+// Panics if there is no current element, i.e. Next() was not called or returned false
+func (oa *ObjectArray) Detach() *Buffer {
+	return oa.CloneAt(oa.curElem)
+}
+
+// CloneAt returns element by index as an independent Buffer with its own bytes. Iteration state is not changed
+// The result is not released on the array owner's Release() and could be used after that or passed to another goroutine
+// Modifications made over ObjectArray.Buffer are not considered. Panics if index is out of range
+func (oa *ObjectArray) CloneAt(idx int) *Buffer {
+	if idx < 0 || idx >= oa.Len {
+		panic(fmt.Sprintf("index out of range: %d of %d", idx, oa.Len))
+	}
+	elem := NewBuffer(oa.Buffer.Scheme)
+	elem.tab.Bytes = oa.Buffer.tab.Bytes
+	elem.tab.Pos = elem.tab.Indirect(oa.start + flatbuffers.UOffsetT(oa.Len-1-idx)*flatbuffers.SizeUOffsetT)
+	res, _ := elem.Clone() // no errors should be here
+	elem.Release()
+	return res
+}
+
 // Release returns used ObjectArray instance to the pool. Releases also ObjectArray.Buffer
 // Note: ObjectArray instance itself, ObjectArray.Buffer, result of ObjectArray.Buffer.ToBytes() must  not be used after Release()
 func (oa *ObjectArray) Release() {
@@ -171,8 +291,10 @@ func (b *Buffer) getAllValues(start flatbuffers.UOffsetT, f *Field) interface{}
 		}
 		return res
 	case FieldTypeByte:
-		intf := getImplIByteArray(b, start)
-		return intf.Bytes()
+		if intf := b.getByteArrayByUOffsetT(f, start); intf != nil {
+			return intf.Bytes()
+		}
+		return []byte(nil) // decode error
 	case FieldTypeBool:
 		intf := getImplIBoolArray(b, start)
 		res := make([]bool, intf.Len())
@@ -223,6 +345,7 @@ func NewBuffer(scheme *Scheme) *Buffer {
 	b.isReleased = false
 	b.toRelease = b.toRelease[:0]
 	b.owner = nil
+	b.redaction = nil
 	b.Reset(nil)
 
 	return b
@@ -249,6 +372,27 @@ func (b *Buffer) releaseFieldsToBytes() {
 	}
 }
 
+// releaseFieldsToBytesLater resets modifications. Pooled values are released on the Buffer release
+func (b *Buffer) releaseFieldsToBytesLater() {
+	for idx := range b.fieldsToBytes {
+		m := &b.fieldsToBytes[idx]
+		if m.hasValue {
+			switch typed := m.value.(type) {
+			case IRelease:
+				b.toRelease = append(b.toRelease, typed)
+			case []*Buffer:
+				for _, bNested := range typed {
+					if bNested != nil {
+						b.toRelease = append(b.toRelease, bNested)
+					}
+				}
+			}
+			m.value = nil
+		}
+		m.Release()
+	}
+}
+
 // GetInt16 returns int16 value by name and if the Scheme contains the field and the value was set to non-nil
 func (b *Buffer) GetInt16(name string) (int16, bool) {
 	if o := b.getFieldUOffsetT(name); o != 0 {
@@ -276,7 +420,7 @@ func (b *Buffer) GetFloat32(name string) (float32, bool) {
 // GetString returns string value by name and if the Scheme contains the field and if the value was set to non-nil
 func (b *Buffer) GetString(name string) (string, bool) {
 	if o := b.getFieldUOffsetT(name); o != 0 {
-		return byteSliceToString(b.tab.ByteVector(o)), true
+		return b.getStringByUOffsetT(b.Scheme.FieldsMap[name], o)
 	}
 	return "", false
 }
@@ -313,6 +457,94 @@ func (b *Buffer) GetBool(name string) (bool, bool) {
 	return false, false
 }
 
+// GetInt16F returns int16 value by the Field and if the value was set to non-nil
+func (b *Buffer) GetInt16F(f *Field) (int16, bool) {
+	b.checkField(f)
+	if o := b.getFieldUOffsetTByOrder(f.Order); o != 0 {
+		return b.tab.GetInt16(o), true
+	}
+	return 0, false
+}
+
+// GetInt32F returns int32 value by the Field and if the value was set to non-nil
+func (b *Buffer) GetInt32F(f *Field) (int32, bool) {
+	b.checkField(f)
+	if o := b.getFieldUOffsetTByOrder(f.Order); o != 0 {
+		return b.tab.GetInt32(o), true
+	}
+	return 0, false
+}
+
+// GetInt64F returns int64 value by the Field and if the value was set to non-nil
+func (b *Buffer) GetInt64F(f *Field) (int64, bool) {
+	b.checkField(f)
+	if o := b.getFieldUOffsetTByOrder(f.Order); o != 0 {
+		return b.tab.GetInt64(o), true
+	}
+	return 0, false
+}
+
+// GetFloat32F returns float32 value by the Field and if the value was set to non-nil
+func (b *Buffer) GetFloat32F(f *Field) (float32, bool) {
+	b.checkField(f)
+	if o := b.getFieldUOffsetTByOrder(f.Order); o != 0 {
+		return b.tab.GetFloat32(o), true
+	}
+	return 0, false
+}
+
+// GetFloat64F returns float64 value by the Field and if the value was set to non-nil
+func (b *Buffer) GetFloat64F(f *Field) (float64, bool) {
+	b.checkField(f)
+	if o := b.getFieldUOffsetTByOrder(f.Order); o != 0 {
+		return b.tab.GetFloat64(o), true
+	}
+	return 0, false
+}
+
+// GetByteF returns byte value by the Field and if the value was set to non-nil
+func (b *Buffer) GetByteF(f *Field) (byte, bool) {
+	b.checkField(f)
+	if o := b.getFieldUOffsetTByOrder(f.Order); o != 0 {
+		return b.tab.GetByte(o), true
+	}
+	return 0, false
+}
+
+// GetBoolF returns bool value by the Field and if the value was set to non-nil
+func (b *Buffer) GetBoolF(f *Field) (bool, bool) {
+	b.checkField(f)
+	if o := b.getFieldUOffsetTByOrder(f.Order); o != 0 {
+		return b.tab.GetBool(o), true
+	}
+	return false, false
+}
+
+// GetStringF returns string value by the Field and if the value was set to non-nil
+func (b *Buffer) GetStringF(f *Field) (string, bool) {
+	b.checkField(f)
+	if o := b.getFieldUOffsetTByOrder(f.Order); o != 0 {
+		return b.getStringByUOffsetT(f, o)
+	}
+	return "", false
+}
+
+// HasValueF returns if the value of the Field is set
+func (b *Buffer) HasValueF(f *Field) bool {
+	b.checkField(f)
+	return b.getFieldUOffsetTByOrder(f.Order) != 0
+}
+
+// checkField panics if the field is nil or does not belong to the Buffer's Scheme
+func (b *Buffer) checkField(f *Field) {
+	if f == nil {
+		panic("nil Field provided")
+	}
+	if f.ownerScheme != b.Scheme {
+		panic(fmt.Sprintf("field %s does not belong to the Buffer's Scheme", f.QualifiedName()))
+	}
+}
+
 func (b *Buffer) getFieldUOffsetT(name string) flatbuffers.UOffsetT {
 	if len(b.tab.Bytes) > 0 {
 		if f, ok := b.Scheme.FieldsMap[name]; ok {
@@ -348,6 +580,7 @@ func (b *Buffer) getByUOffsetT(f *Field, uOffsetT flatbuffers.UOffsetT) interfac
 			arr.curElem = -1
 			arr.start = b.tab.Vector(uOffsetT - b.tab.Pos)
 			arr.Buffer.tab.Bytes = b.tab.Bytes
+			arr.Buffer.owner = b
 			b.toRelease = append(b.toRelease, arr)
 			return arr
 		}
@@ -379,7 +612,10 @@ func (b *Buffer) getByUOffsetT(f *Field, uOffsetT flatbuffers.UOffsetT) interfac
 		b.set(f, res)
 		return res
 	default:
-		return byteSliceToString(b.tab.ByteVector(uOffsetT))
+		if res, ok := b.getStringByUOffsetT(f, uOffsetT); ok {
+			return res
+		}
+		return nil // decode error
 	}
 }
 
@@ -426,7 +662,10 @@ func (b *Buffer) getArrIntf(f *Field) interface{} {
 	case FieldTypeFloat64:
 		return getImplIFloat64Array(b, uOffsetT)
 	case FieldTypeByte:
-		return getImplIByteArray(b, uOffsetT)
+		if res := b.getByteArrayByUOffsetT(f, uOffsetT); res != nil {
+			return res
+		}
+		return nil // decode error
 	case FieldTypeString:
 		return getImplIStringArray(b, uOffsetT)
 	case FieldTypeBool:
@@ -489,7 +728,7 @@ func (b *Buffer) GetByteArray(name string) IByteArray {
 	if uOffsetT == 0 {
 		return nil
 	}
-	return getImplIByteArray(b, uOffsetT)
+	return b.getByteArrayByUOffsetT(b.Scheme.FieldsMap[name], uOffsetT)
 }
 
 func (b *Buffer) GetBoolArray(name string) IBoolArray {
@@ -500,6 +739,86 @@ func (b *Buffer) GetBoolArray(name string) IBoolArray {
 	return getImplIBoolArray(b, uOffsetT)
 }
 
+// GetInt16ArrayF returns int16 array by the Field, nil if unset
+func (b *Buffer) GetInt16ArrayF(f *Field) IInt16Array {
+	b.checkField(f)
+	uOffsetT := b.getFieldUOffsetTByOrder(f.Order)
+	if uOffsetT == 0 {
+		return nil
+	}
+	return getImplIInt16Array(b, uOffsetT)
+}
+
+// GetInt32ArrayF returns int32 array by the Field, nil if unset
+func (b *Buffer) GetInt32ArrayF(f *Field) IInt32Array {
+	b.checkField(f)
+	uOffsetT := b.getFieldUOffsetTByOrder(f.Order)
+	if uOffsetT == 0 {
+		return nil
+	}
+	return getImplIInt32Array(b, uOffsetT)
+}
+
+// GetInt64ArrayF returns int64 array by the Field, nil if unset
+func (b *Buffer) GetInt64ArrayF(f *Field) IInt64Array {
+	b.checkField(f)
+	uOffsetT := b.getFieldUOffsetTByOrder(f.Order)
+	if uOffsetT == 0 {
+		return nil
+	}
+	return getImplIInt64Array(b, uOffsetT)
+}
+
+// GetFloat32ArrayF returns float32 array by the Field, nil if unset
+func (b *Buffer) GetFloat32ArrayF(f *Field) IFloat32Array {
+	b.checkField(f)
+	uOffsetT := b.getFieldUOffsetTByOrder(f.Order)
+	if uOffsetT == 0 {
+		return nil
+	}
+	return getImplIFloat32Array(b, uOffsetT)
+}
+
+// GetFloat64ArrayF returns float64 array by the Field, nil if unset
+func (b *Buffer) GetFloat64ArrayF(f *Field) IFloat64Array {
+	b.checkField(f)
+	uOffsetT := b.getFieldUOffsetTByOrder(f.Order)
+	if uOffsetT == 0 {
+		return nil
+	}
+	return getImplIFloat64Array(b, uOffsetT)
+}
+
+// GetStringArrayF returns string array by the Field, nil if unset
+func (b *Buffer) GetStringArrayF(f *Field) IStringArray {
+	b.checkField(f)
+	uOffsetT := b.getFieldUOffsetTByOrder(f.Order)
+	if uOffsetT == 0 {
+		return nil
+	}
+	return getImplIStringArray(b, uOffsetT)
+}
+
+// GetByteArrayF returns byte array by the Field, nil if unset
+func (b *Buffer) GetByteArrayF(f *Field) IByteArray {
+	b.checkField(f)
+	uOffsetT := b.getFieldUOffsetTByOrder(f.Order)
+	if uOffsetT == 0 {
+		return nil
+	}
+	return b.getByteArrayByUOffsetT(f, uOffsetT)
+}
+
+// GetBoolArrayF returns bool array by the Field, nil if unset
+func (b *Buffer) GetBoolArrayF(f *Field) IBoolArray {
+	b.checkField(f)
+	uOffsetT := b.getFieldUOffsetTByOrder(f.Order)
+	if uOffsetT == 0 {
+		return nil
+	}
+	return getImplIBoolArray(b, uOffsetT)
+}
+
 func getImplIInt16Array(b *Buffer, uOffsetT flatbuffers.UOffsetT) IInt16Array {
 	return implIInt16Array{
 		abstractArray: abstractArray{
@@ -587,11 +906,95 @@ func ReadBuffer(bytes []byte, scheme *Scheme) *Buffer {
 	return b
 }
 
+// ReadOption specifies a check made by ReadBufferWithOptions()
+type ReadOption func(opts *readOptions)
+
+type readOptions struct {
+	verifyOptions       *VerifyOptions
+	checkMandatory      bool
+	maxDecompressedSize int
+	unpooledBytes       bool
+}
+
+// WithVerification makes ReadBufferWithOptions() to check bytes using VerifyWithOptions() before read
+func WithVerification(verifyOptions VerifyOptions) ReadOption {
+	return func(opts *readOptions) {
+		opts.verifyOptions = &verifyOptions
+	}
+}
+
+// WithMandatoryCheck makes ReadBufferWithOptions() to check mandatory fields using Buffer.CheckMandatory()
+func WithMandatoryCheck() ReadOption {
+	return func(opts *readOptions) {
+		opts.checkMandatory = true
+	}
+}
+
+// WithMaxDecompressedSize limits the uncompressed size declared by the envelope read by ReadBufferCompressed(). DefaultMaxRecordSize by default
+func WithMaxDecompressedSize(size int) ReadOption {
+	return func(opts *readOptions) {
+		opts.maxDecompressedSize = size
+	}
+}
+
+// WithUnpooledBytes makes ReadBufferCompressed() to decompress into a newly allocated slice instead of a pooled one
+// Strings and slices got from the Buffer are then valid after the Buffer release at the cost of an allocation per read
+func WithUnpooledBytes() ReadOption {
+	return func(opts *readOptions) {
+		opts.unpooledBytes = true
+	}
+}
+
+// ReadBufferWithOptions creates Buffer from bytes using provided Scheme and makes checks specified by options
+// Returns nil Buffer if any check is failed
+func ReadBufferWithOptions(bytes []byte, scheme *Scheme, opts ...ReadOption) (*Buffer, error) {
+	ro := newReadOptions(opts)
+	return ro.read(bytes, scheme)
+}
+
+func newReadOptions(opts []ReadOption) readOptions {
+	res := readOptions{maxDecompressedSize: DefaultMaxRecordSize}
+	for _, opt := range opts {
+		opt(&res)
+	}
+	return res
+}
+
+func (ro *readOptions) read(bytes []byte, scheme *Scheme) (*Buffer, error) {
+	if err := ro.checkBytes(bytes, scheme); err != nil {
+		return nil, err
+	}
+	b := ReadBuffer(bytes, scheme)
+	if err := ro.checkBuffer(b); err != nil {
+		b.Release()
+		return nil, err
+	}
+	return b, nil
+}
+
+// checkBytes makes checks which must be done before bytes are read
+func (ro *readOptions) checkBytes(bytes []byte, scheme *Scheme) error {
+	if ro.verifyOptions != nil {
+		return VerifyWithOptions(bytes, scheme, *ro.verifyOptions)
+	}
+	return nil
+}
+
+// checkBuffer makes checks of the read Buffer
+func (ro *readOptions) checkBuffer(b *Buffer) error {
+	if ro.checkMandatory {
+		return b.CheckMandatory()
+	}
+	return nil
+}
+
 // Set sets field value by name.
 // Call ToBytes() to get modified byte array
 // Value for byte array field could be base64 string or []byte
 // `Get()` will not consider modifications made by Set, Append, ApplyJSONAndToBytes, ApplyMapBuffer, ApplyMap
 // Rewrites previous modifications made by Set, Append, ApplyJSONAndToBytes, ApplyMapBuffer, ApplyMap
+// Typed setters (SetInt32(), SetStringArray() etc) store values without interface boxing. Value of a typed setter which differs from
+// the field type is encoded by Set() rules: e.g. float64 is converted to an integer field if fits, error on ToBytes() otherwise
 func (b *Buffer) Set(name string, value interface{}) {
 	f, ok := b.Scheme.FieldsMap[name]
 	if !ok {
@@ -600,6 +1003,12 @@ func (b *Buffer) Set(name string, value interface{}) {
 	b.set(f, value)
 }
 
+// SetF sets field value by the Field
+func (b *Buffer) SetF(f *Field, value interface{}) {
+	b.checkField(f)
+	b.set(f, value)
+}
+
 func (b *Buffer) set(f *Field, value interface{}) {
 	b.prepareFieldsToBytes()
 	m := &b.fieldsToBytes[f.Order]
@@ -624,6 +1033,243 @@ func (b *Buffer) set(f *Field, value interface{}) {
 
 	m.value = value
 	m.isAppend = false
+	m.resetTyped()
+	m.resetArrayOps()
+}
+
+func (b *Buffer) setTyped(f *Field, ft FieldType, isArray bool, scalar uint64, arr unsafe.Pointer, arrLen int) {
+	b.set(f, nil)
+	m := &b.fieldsToBytes[f.Order]
+	m.typedFt = ft
+	m.typedIsArray = isArray
+	m.typedScalar = scalar
+	m.typedArr = arr
+	m.typedArrLen = arrLen
+}
+
+// SetInt16 is a typed analogue of Set(). Value is stored without interface boxing
+func (b *Buffer) SetInt16(name string, value int16) {
+	if f, ok := b.Scheme.FieldsMap[name]; ok {
+		b.setTyped(f, FieldTypeInt16, false, uint64(value), nil, 0)
+	}
+}
+
+// SetInt32 is a typed analogue of Set(). Value is stored without interface boxing
+func (b *Buffer) SetInt32(name string, value int32) {
+	if f, ok := b.Scheme.FieldsMap[name]; ok {
+		b.setTyped(f, FieldTypeInt32, false, uint64(value), nil, 0)
+	}
+}
+
+// SetInt64 is a typed analogue of Set(). Value is stored without interface boxing
+func (b *Buffer) SetInt64(name string, value int64) {
+	if f, ok := b.Scheme.FieldsMap[name]; ok {
+		b.setTyped(f, FieldTypeInt64, false, uint64(value), nil, 0)
+	}
+}
+
+// SetFloat32 is a typed analogue of Set(). Value is stored without interface boxing
+func (b *Buffer) SetFloat32(name string, value float32) {
+	if f, ok := b.Scheme.FieldsMap[name]; ok {
+		b.setTyped(f, FieldTypeFloat32, false, uint64(math.Float32bits(value)), nil, 0)
+	}
+}
+
+// SetFloat64 is a typed analogue of Set(). Value is stored without interface boxing
+func (b *Buffer) SetFloat64(name string, value float64) {
+	if f, ok := b.Scheme.FieldsMap[name]; ok {
+		b.setTyped(f, FieldTypeFloat64, false, math.Float64bits(value), nil, 0)
+	}
+}
+
+// SetBool is a typed analogue of Set(). Value is stored without interface boxing
+func (b *Buffer) SetBool(name string, value bool) {
+	if f, ok := b.Scheme.FieldsMap[name]; ok {
+		scalar := uint64(0)
+		if value {
+			scalar = 1
+		}
+		b.setTyped(f, FieldTypeBool, false, scalar, nil, 0)
+	}
+}
+
+// SetByte is a typed analogue of Set(). Value is stored without interface boxing
+func (b *Buffer) SetByte(name string, value byte) {
+	if f, ok := b.Scheme.FieldsMap[name]; ok {
+		b.setTyped(f, FieldTypeByte, false, uint64(value), nil, 0)
+	}
+}
+
+// SetString is a typed analogue of Set(). Value is stored without interface boxing
+// Empty string means unset the field
+func (b *Buffer) SetString(name string, value string) {
+	if f, ok := b.Scheme.FieldsMap[name]; ok {
+		b.setTyped(f, FieldTypeString, false, 0, unsafe.Pointer(unsafe.StringData(value)), len(value))
+	}
+}
+
+// SetInt16Array is a typed analogue of Set() for arrays. The slice is stored without interface boxing and must not be modified until ToBytes()
+// Nil or empty array means unset the field
+func (b *Buffer) SetInt16Array(name string, value []int16) {
+	if f, ok := b.Scheme.FieldsMap[name]; ok {
+		b.setTyped(f, FieldTypeInt16, true, 0, unsafe.Pointer(unsafe.SliceData(value)), len(value))
+	}
+}
+
+// SetInt32Array is a typed analogue of Set() for arrays. The slice is stored without interface boxing and must not be modified until ToBytes()
+// Nil or empty array means unset the field
+func (b *Buffer) SetInt32Array(name string, value []int32) {
+	if f, ok := b.Scheme.FieldsMap[name]; ok {
+		b.setTyped(f, FieldTypeInt32, true, 0, unsafe.Pointer(unsafe.SliceData(value)), len(value))
+	}
+}
+
+// SetInt64Array is a typed analogue of Set() for arrays. The slice is stored without interface boxing and must not be modified until ToBytes()
+// Nil or empty array means unset the field
+func (b *Buffer) SetInt64Array(name string, value []int64) {
+	if f, ok := b.Scheme.FieldsMap[name]; ok {
+		b.setTyped(f, FieldTypeInt64, true, 0, unsafe.Pointer(unsafe.SliceData(value)), len(value))
+	}
+}
+
+// SetFloat32Array is a typed analogue of Set() for arrays. The slice is stored without interface boxing and must not be modified until ToBytes()
+// Nil or empty array means unset the field
+func (b *Buffer) SetFloat32Array(name string, value []float32) {
+	if f, ok := b.Scheme.FieldsMap[name]; ok {
+		b.setTyped(f, FieldTypeFloat32, true, 0, unsafe.Pointer(unsafe.SliceData(value)), len(value))
+	}
+}
+
+// SetFloat64Array is a typed analogue of Set() for arrays. The slice is stored without interface boxing and must not be modified until ToBytes()
+// Nil or empty array means unset the field
+func (b *Buffer) SetFloat64Array(name string, value []float64) {
+	if f, ok := b.Scheme.FieldsMap[name]; ok {
+		b.setTyped(f, FieldTypeFloat64, true, 0, unsafe.Pointer(unsafe.SliceData(value)), len(value))
+	}
+}
+
+// SetBoolArray is a typed analogue of Set() for arrays. The slice is stored without interface boxing and must not be modified until ToBytes()
+// Nil or empty array means unset the field
+func (b *Buffer) SetBoolArray(name string, value []bool) {
+	if f, ok := b.Scheme.FieldsMap[name]; ok {
+		b.setTyped(f, FieldTypeBool, true, 0, unsafe.Pointer(unsafe.SliceData(value)), len(value))
+	}
+}
+
+// SetByteArray is a typed analogue of Set() for byte arrays. The slice is stored without interface boxing and must not be modified until ToBytes()
+// Nil or empty array means unset the field
+func (b *Buffer) SetByteArray(name string, value []byte) {
+	if f, ok := b.Scheme.FieldsMap[name]; ok {
+		b.setTyped(f, FieldTypeByte, true, 0, unsafe.Pointer(unsafe.SliceData(value)), len(value))
+	}
+}
+
+// SetStringArray is a typed analogue of Set() for arrays. The slice is stored without interface boxing and must not be modified until ToBytes()
+// Nil or empty array means unset the field
+func (b *Buffer) SetStringArray(name string, value []string) {
+	if f, ok := b.Scheme.FieldsMap[name]; ok {
+		b.setTyped(f, FieldTypeString, true, 0, unsafe.Pointer(unsafe.SliceData(value)), len(value))
+	}
+}
+
+// SetInt16F sets int16 value by the Field without interface boxing
+func (b *Buffer) SetInt16F(f *Field, value int16) {
+	b.checkField(f)
+	b.setTyped(f, FieldTypeInt16, false, uint64(value), nil, 0)
+}
+
+// SetInt32F sets int32 value by the Field without interface boxing
+func (b *Buffer) SetInt32F(f *Field, value int32) {
+	b.checkField(f)
+	b.setTyped(f, FieldTypeInt32, false, uint64(value), nil, 0)
+}
+
+// SetInt64F sets int64 value by the Field without interface boxing
+func (b *Buffer) SetInt64F(f *Field, value int64) {
+	b.checkField(f)
+	b.setTyped(f, FieldTypeInt64, false, uint64(value), nil, 0)
+}
+
+// SetFloat32F sets float32 value by the Field without interface boxing
+func (b *Buffer) SetFloat32F(f *Field, value float32) {
+	b.checkField(f)
+	b.setTyped(f, FieldTypeFloat32, false, uint64(math.Float32bits(value)), nil, 0)
+}
+
+// SetFloat64F sets float64 value by the Field without interface boxing
+func (b *Buffer) SetFloat64F(f *Field, value float64) {
+	b.checkField(f)
+	b.setTyped(f, FieldTypeFloat64, false, math.Float64bits(value), nil, 0)
+}
+
+// SetByteF sets byte value by the Field without interface boxing
+func (b *Buffer) SetByteF(f *Field, value byte) {
+	b.checkField(f)
+	b.setTyped(f, FieldTypeByte, false, uint64(value), nil, 0)
+}
+
+// SetBoolF sets bool value by the Field without interface boxing
+func (b *Buffer) SetBoolF(f *Field, value bool) {
+	b.checkField(f)
+	scalar := uint64(0)
+	if value {
+		scalar = 1
+	}
+	b.setTyped(f, FieldTypeBool, false, scalar, nil, 0)
+}
+
+// SetStringF sets string value by the Field without interface boxing
+func (b *Buffer) SetStringF(f *Field, value string) {
+	b.checkField(f)
+	b.setTyped(f, FieldTypeString, false, 0, unsafe.Pointer(unsafe.StringData(value)), len(value))
+}
+
+// SetInt16ArrayF sets int16 array by the Field without interface boxing
+func (b *Buffer) SetInt16ArrayF(f *Field, value []int16) {
+	b.checkField(f)
+	b.setTyped(f, FieldTypeInt16, true, 0, unsafe.Pointer(unsafe.SliceData(value)), len(value))
+}
+
+// SetInt32ArrayF sets int32 array by the Field without interface boxing
+func (b *Buffer) SetInt32ArrayF(f *Field, value []int32) {
+	b.checkField(f)
+	b.setTyped(f, FieldTypeInt32, true, 0, unsafe.Pointer(unsafe.SliceData(value)), len(value))
+}
+
+// SetInt64ArrayF sets int64 array by the Field without interface boxing
+func (b *Buffer) SetInt64ArrayF(f *Field, value []int64) {
+	b.checkField(f)
+	b.setTyped(f, FieldTypeInt64, true, 0, unsafe.Pointer(unsafe.SliceData(value)), len(value))
+}
+
+// SetFloat32ArrayF sets float32 array by the Field without interface boxing
+func (b *Buffer) SetFloat32ArrayF(f *Field, value []float32) {
+	b.checkField(f)
+	b.setTyped(f, FieldTypeFloat32, true, 0, unsafe.Pointer(unsafe.SliceData(value)), len(value))
+}
+
+// SetFloat64ArrayF sets float64 array by the Field without interface boxing
+func (b *Buffer) SetFloat64ArrayF(f *Field, value []float64) {
+	b.checkField(f)
+	b.setTyped(f, FieldTypeFloat64, true, 0, unsafe.Pointer(unsafe.SliceData(value)), len(value))
+}
+
+// SetBoolArrayF sets bool array by the Field without interface boxing
+func (b *Buffer) SetBoolArrayF(f *Field, value []bool) {
+	b.checkField(f)
+	b.setTyped(f, FieldTypeBool, true, 0, unsafe.Pointer(unsafe.SliceData(value)), len(value))
+}
+
+// SetByteArrayF sets byte array by the Field without interface boxing
+func (b *Buffer) SetByteArrayF(f *Field, value []byte) {
+	b.checkField(f)
+	b.setTyped(f, FieldTypeByte, true, 0, unsafe.Pointer(unsafe.SliceData(value)), len(value))
+}
+
+// SetStringArrayF sets string array by the Field without interface boxing
+func (b *Buffer) SetStringArrayF(f *Field, value []string) {
+	b.checkField(f)
+	b.setTyped(f, FieldTypeString, true, 0, unsafe.Pointer(unsafe.SliceData(value)), len(value))
 }
 
 // Append appends an array field. toAppend could be a single value or an array of values
@@ -645,10 +1291,17 @@ func (b *Buffer) append(f *Field, toAppend interface{}) {
 
 	m := &b.fieldsToBytes[f.Order]
 
+	if len(m.arrayOps) > 0 {
+		// must be applied after pending operations
+		m.arrayOps = append(m.arrayOps, arrayOp{kind: arrayOpAppend, value: toAppend})
+		return
+	}
+
 	m.hasValue = true
 
 	m.value = toAppend
 	m.isAppend = true
+	m.resetTyped()
 }
 
 // ApplyMapBuffer modifies Buffer with JSON specified by jsonMap
@@ -683,13 +1336,44 @@ func (b *Buffer) ToBytesNilled() (res []byte, nilledFields []string, err error)
 	}
 	for i := range b.fieldsToBytes {
 		ftb := &b.fieldsToBytes[i]
-		if ftb.hasValue && (ftb.value == nil || ftb.isValueEmpty) {
+		if len(ftb.arrayOps) > 0 {
+			if ftb.isValueEmpty {
+				nilledFields = append(nilledFields, b.Scheme.Fields[i].Name)
+			}
+		} else if ftb.hasValue && (ftb.isNil() || ftb.isValueEmpty) {
 			nilledFields = append(nilledFields, b.Scheme.Fields[i].Name)
 		}
 	}
 	return
 }
 
+// mapsToBuffersSlice converts []interface{} of map[string]interface{} to nested Buffers of array field `f` using ApplyMap()
+func (b *Buffer) mapsToBuffersSlice(f *Field, fv interface{}) (*buffersSlice, error) {
+	datasNested, ok := fv.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("array of objects required but %#v provided for field %s", fv, f.QualifiedName())
+	}
+
+	buffers := getBufferSlice(len(datasNested))
+
+	for i, dataNestedIntf := range datasNested {
+		dataNested, ok := dataNestedIntf.(map[string]interface{})
+
+		if !ok {
+			buffers.Release()
+			return nil, fmt.Errorf("element value of array field %s must be an object, %#v provided", f.Name, dataNestedIntf)
+		}
+
+		buffers.Slice[i] = NewBuffer(f.FieldScheme)
+		buffers.Slice[i].owner = b
+		if err := buffers.Slice[i].ApplyMap(dataNested); err != nil {
+			buffers.Release()
+			return nil, err
+		}
+	}
+	return buffers, nil
+}
+
 // ApplyMap sets field values described by provided map[string]interface{}
 // Resulting buffer has no value (or has nil value) for a mandatory field -> error
 // Value type and field type are incompatible (e.g. string for numberic field) -> error
@@ -720,29 +1404,10 @@ func (b *Buffer) ApplyMap(data map[string]interface{}) error {
 
 		if f.Ft == FieldTypeObject {
 			if f.IsArray {
-				datasNested, ok := fv.([]interface{})
-				if !ok {
-					return fmt.Errorf("array of objects required but %#v provided for field %s", fv, f.QualifiedName())
-				}
-
-				buffers := getBufferSlice(len(datasNested))
-
-				for i, dataNestedIntf := range datasNested {
-					dataNested, ok := dataNestedIntf.(map[string]interface{})
-
-					if !ok {
-						buffers.Release()
-						return fmt.Errorf("element value of array field %s must be an object, %#v provided", fn, dataNestedIntf)
-					}
-
-					buffers.Slice[i] = NewBuffer(f.FieldScheme)
-					buffers.Slice[i].owner = b
-					if err := buffers.Slice[i].ApplyMap(dataNested); err != nil {
-						buffers.Release()
-						return err
-					}
+				buffers, err := b.mapsToBuffersSlice(f, fv)
+				if err != nil {
+					return err
 				}
-
 				b.append(f, buffers)
 			} else {
 				bNested := NewBuffer(f.FieldScheme)
@@ -1028,7 +1693,7 @@ func (b *Buffer) NKeys() int {
 func (b *Buffer) ToBytes() ([]byte, error) {
 	b.builder.Reset()
 
-	uOffset, err := b.encodeBuffer(b.builder)
+	uOffset, err := b.encodeRoot(b.builder)
 	if err != nil {
 		return nil, err
 	}
@@ -1040,10 +1705,40 @@ func (b *Buffer) ToBytes() ([]byte, error) {
 	return nil, nil
 }
 
+// ToBytesPooled is an analogue of ToBytes() but the result is owned by the caller and is not damaged by further ToBytes() or Release() of the Buffer
+// Useful to keep several results at once. The result must be released by IBytes.Release() when it is not needed anymore
+// Result is backed by a pooled builder. Nothing to encode -> IBytes.Bytes() returns nil
+func (b *Buffer) ToBytesPooled() (IBytes, error) {
+	res := getPooledBytes()
+	uOffset, err := b.encodeRoot(res.builder)
+	if err != nil {
+		res.Release()
+		return nil, err
+	}
+	if uOffset != 0 {
+		res.bytes = res.builder.FinishedBytes()
+	}
+	return res, nil
+}
+
+// Clone returns an independent Buffer with its own bytes which contain current bytes and pending modifications
+// Nested Buffer got by Get() -> cloned as a root object
+// The result is not bound to the Buffer and its owner: it is not released on their Release() and could be passed to another goroutine
+// Error is returned if pending modifications could not be encoded, see ToBytes()
+func (b *Buffer) Clone() (*Buffer, error) {
+	bytes, err := b.ToBytesPooled()
+	if err != nil {
+		return nil, err
+	}
+	res := ReadBuffer(copyBytes(bytes.Bytes()), b.Scheme)
+	bytes.Release()
+	return res, nil
+}
+
 // ToBytesWithBuilder same as ToBytes but uses builder
 // note: caller side must use `builder.FinishedBytes()` instead of `builder.Bytes`
 func (b *Buffer) ToBytesWithBuilder(builder *flatbuffers.Builder) error {
-	_, err := b.encodeBuffer(builder)
+	_, err := b.encodeRoot(builder)
 	return err
 }
 
@@ -1069,13 +1764,30 @@ func (b *Buffer) encodeBuffer(bl *flatbuffers.Builder) (flatbuffers.UOffsetT, er
 		if f.IsArray {
 			arrayUOffsetT := flatbuffers.UOffsetT(0)
 			fieldToBytes := &b.fieldsToBytes[f.Order]
-			if fieldToBytes.hasValue {
-				if fieldToBytes.value != nil {
+			if f.transformer != nil && (len(fieldToBytes.arrayOps) > 0 || fieldToBytes.hasValue) {
+				if arrayUOffsetT, err = b.encodeTransformedByteArray(bl, f, fieldToBytes); err != nil {
+					return 0, err
+				}
+				fieldToBytes.isValueEmpty = arrayUOffsetT == 0
+			} else if len(fieldToBytes.arrayOps) > 0 {
+				arr, err := b.getArrayWithOps(f, fieldToBytes)
+				if err != nil {
+					return 0, err
+				}
+				if arrayUOffsetT, err = b.encodeArray(bl, f, arr, nil); err != nil {
+					return 0, err
+				}
+				fieldToBytes.isValueEmpty = arrayUOffsetT == 0
+			} else if fieldToBytes.hasValue {
+				if fieldToBytes.typedFt == f.Ft && fieldToBytes.typedIsArray {
+					arrayUOffsetT = encodeTypedArray(bl, fieldToBytes)
+					fieldToBytes.isValueEmpty = arrayUOffsetT == 0
+				} else if !fieldToBytes.isNil() {
 					var toAppendToIntf interface{} = nil
 					if fieldToBytes.isAppend {
 						toAppendToIntf = b.getArrIntf(f)
 					}
-					if arrayUOffsetT, err = b.encodeArray(bl, f, fieldToBytes.value, toAppendToIntf); err != nil {
+					if arrayUOffsetT, err = b.encodeArray(bl, f, fieldToBytes.getValue(), toAppendToIntf); err != nil {
 						return 0, err
 					}
 					fieldToBytes.isValueEmpty = arrayUOffsetT == 0
@@ -1090,9 +1802,9 @@ func (b *Buffer) encodeBuffer(bl *flatbuffers.Builder) (flatbuffers.UOffsetT, er
 			nestedUOffsetT := flatbuffers.UOffsetT(0)
 			fieldToBytes := &b.fieldsToBytes[f.Order]
 			if fieldToBytes.hasValue {
-				if fieldToBytes.value != nil {
+				if !fieldToBytes.isNil() {
 					if nestedBuffer, ok := fieldToBytes.value.(*Buffer); !ok {
-						return 0, fmt.Errorf("nested object required but %#v provided for field %s", fieldToBytes.value, f.QualifiedName())
+						return 0, fmt.Errorf("nested object required but %#v provided for field %s", fieldToBytes.getValue(), f.QualifiedName())
 					} else if nestedUOffsetT, err = nestedBuffer.encodeBuffer(bl); err != nil {
 						return 0, err
 					}
@@ -1108,9 +1820,21 @@ func (b *Buffer) encodeBuffer(bl *flatbuffers.Builder) (flatbuffers.UOffsetT, er
 		} else if f.Ft == FieldTypeString {
 			stringUOffsetT := flatbuffers.UOffsetT(0)
 			stringFieldToBytes := &b.fieldsToBytes[f.Order]
-			if stringFieldToBytes.hasValue {
-				if stringFieldToBytes.value != nil {
-					switch toWrite := stringFieldToBytes.value.(type) {
+			if stringFieldToBytes.hasValue && f.transformer != nil {
+				if !stringFieldToBytes.isNil() {
+					if stringUOffsetT, err = encodeTransformedString(bl, f, stringFieldToBytes); err != nil {
+						return 0, err
+					}
+				}
+				stringFieldToBytes.isValueEmpty = stringUOffsetT == 0
+			} else if stringFieldToBytes.hasValue {
+				if stringFieldToBytes.typedFt == FieldTypeString && !stringFieldToBytes.typedIsArray {
+					if stringFieldToBytes.typedArrLen > 0 {
+						stringUOffsetT = bl.CreateByteString(unsafe.Slice((*byte)(stringFieldToBytes.typedArr), stringFieldToBytes.typedArrLen))
+					}
+					stringFieldToBytes.isValueEmpty = stringUOffsetT == 0
+				} else if !stringFieldToBytes.isNil() {
+					switch toWrite := stringFieldToBytes.getValue().(type) {
 					case string:
 						if len(toWrite) > 0 {
 							stringUOffsetT = bl.CreateString(toWrite)
@@ -1120,7 +1844,7 @@ func (b *Buffer) encodeBuffer(bl *flatbuffers.Builder) (flatbuffers.UOffsetT, er
 							stringUOffsetT = bl.CreateByteString(toWrite)
 						}
 					default:
-						return 0, fmt.Errorf("string required but %#v provided for field %s", stringFieldToBytes.value, f.QualifiedName())
+						return 0, fmt.Errorf("string required but %#v provided for field %s", toWrite, f.QualifiedName())
 					}
 					stringFieldToBytes.isValueEmpty = stringUOffsetT == 0
 
@@ -1134,7 +1858,7 @@ func (b *Buffer) encodeBuffer(bl *flatbuffers.Builder) (flatbuffers.UOffsetT, er
 		}
 	}
 
-	isStarted := false
+	isStarted := b.copyUnknownFields(bl)
 	beforePrepend := func() {
 		if !isStarted {
 			bl.StartObject(len(b.Scheme.Fields))
@@ -1155,9 +1879,11 @@ func (b *Buffer) encodeBuffer(bl *flatbuffers.Builder) (flatbuffers.UOffsetT, er
 			default:
 				fieldToBytes := &b.fieldsToBytes[f.Order]
 				if fieldToBytes.hasValue {
-					if isSet = fieldToBytes.value != nil; isSet {
-						if !encodeFixedSizeValue(bl, f, fieldToBytes.value, beforePrepend) {
-							return 0, fmt.Errorf("wrong value %T(%#v) provided for field %s", fieldToBytes.value, fieldToBytes.value, f.QualifiedName())
+					if isSet = !fieldToBytes.isNil(); isSet {
+						if fieldToBytes.typedFt == f.Ft && !fieldToBytes.typedIsArray {
+							encodeTypedFixedSizeValue(bl, f, fieldToBytes, beforePrepend)
+						} else if value := fieldToBytes.getValue(); !encodeFixedSizeValue(bl, f, value, beforePrepend) {
+							return 0, fmt.Errorf("wrong value %T(%#v) provided for field %s", value, value, f.QualifiedName())
 						}
 					}
 				} else {
@@ -1175,13 +1901,21 @@ func (b *Buffer) encodeBuffer(bl *flatbuffers.Builder) (flatbuffers.UOffsetT, er
 	}
 
 	if isStarted {
-		res := bl.EndObject()
-		bl.Finish(res)
-		return res, nil
+		return bl.EndObject(), nil
 	}
 	return 0, nil
 }
 
+// encodeRoot encodes the Buffer as the root object and finishes the builder
+// Nested objects are not finished: the root offset written by Finish() would be a garbage between the nested object and data written after it
+func (b *Buffer) encodeRoot(bl *flatbuffers.Builder) (flatbuffers.UOffsetT, error) {
+	uOffsetT, err := b.encodeBuffer(bl)
+	if uOffsetT != 0 {
+		bl.Finish(uOffsetT)
+	}
+	return uOffsetT, err
+}
+
 // HasValue returns if specified field exists in the scheme and its value is set to non-nil
 func (b *Buffer) HasValue(name string) bool {
 	return b.getFieldUOffsetT(name) != 0
@@ -1260,6 +1994,9 @@ func (b *Buffer) copyArray(bl *flatbuffers.Builder, arrayUOffsetT flatbuffers.UO
 		putUOffsetSlice(os)
 		return bl.EndVector(l)
 	default:
+		if resUOffsetT, ok := b.copyRawObjectArray(bl, arrayUOffsetT, f); ok {
+			return resUOffsetT
+		}
 		objectArray := b.getByUOffsetT(f, arrayUOffsetT)
 		resUOffsetT, _ := b.encodeArray(bl, f, objectArray, nil) // no errors should be here
 		return resUOffsetT
@@ -1672,6 +2409,15 @@ func (b *Buffer) encodeArray(bl *flatbuffers.Builder, f *Field, value interface{
 				nestedUOffsetT, _ := arr.Buffer.encodeBuffer(bl) // should be no errors here
 				*nestedUOffsetTs = append(*nestedUOffsetTs, nestedUOffsetT)
 			}
+		case *MutableObjectArray:
+			// came from GetMutableObjectArray()
+			if err := arr.iterate(func(elem *Buffer) error {
+				nestedUOffsetT, err := elem.encodeBuffer(bl)
+				*nestedUOffsetTs = append(*nestedUOffsetTs, nestedUOffsetT)
+				return err
+			}); err != nil {
+				return 0, err
+			}
 
 		default:
 			return 0, fmt.Errorf("%#v provided for field %s is not an array of nested objects", value, f.QualifiedName())
@@ -1872,6 +2618,82 @@ func encodeFixedSizeValue(bl *flatbuffers.Builder, f *Field, value interface{},
 	return true
 }
 
+// encodeTypedFixedSizeValue writes value provided by a typed setter. Value type must match the field type
+func encodeTypedFixedSizeValue(bl *flatbuffers.Builder, f *Field, m *fieldToBytes, beforePrepend func()) {
+	beforePrepend()
+	switch f.Ft {
+	case FieldTypeInt16:
+		bl.PrependInt16(int16(m.typedScalar))
+	case FieldTypeInt32:
+		bl.PrependInt32(int32(m.typedScalar))
+	case FieldTypeInt64:
+		bl.PrependInt64(int64(m.typedScalar))
+	case FieldTypeFloat32:
+		bl.PrependFloat32(math.Float32frombits(uint32(m.typedScalar)))
+	case FieldTypeFloat64:
+		bl.PrependFloat64(math.Float64frombits(m.typedScalar))
+	case FieldTypeBool:
+		bl.PrependBool(m.typedScalar != 0)
+	case FieldTypeByte:
+		bl.PrependByte(byte(m.typedScalar))
+	}
+	bl.Slot(f.Order)
+}
+
+// encodeTypedArray writes array provided by a typed setter. Array element type must match the field type
+// empty array -> 0, i.e. nothing is written
+func encodeTypedArray(bl *flatbuffers.Builder, m *fieldToBytes) flatbuffers.UOffsetT {
+	l := m.typedArrLen
+	if l == 0 {
+		return 0
+	}
+	switch m.typedFt {
+	case FieldTypeInt16:
+		bl.StartVector(flatbuffers.SizeInt16, l, flatbuffers.SizeInt16)
+		for _, elem := range unsafe.Slice((*int16)(m.typedArr), l) {
+			bl.PrependInt16(elem)
+		}
+	case FieldTypeInt32:
+		bl.StartVector(flatbuffers.SizeInt32, l, flatbuffers.SizeInt32)
+		for _, elem := range unsafe.Slice((*int32)(m.typedArr), l) {
+			bl.PrependInt32(elem)
+		}
+	case FieldTypeInt64:
+		bl.StartVector(flatbuffers.SizeInt64, l, flatbuffers.SizeInt64)
+		for _, elem := range unsafe.Slice((*int64)(m.typedArr), l) {
+			bl.PrependInt64(elem)
+		}
+	case FieldTypeFloat32:
+		bl.StartVector(flatbuffers.SizeFloat32, l, flatbuffers.SizeFloat32)
+		for _, elem := range unsafe.Slice((*float32)(m.typedArr), l) {
+			bl.PrependFloat32(elem)
+		}
+	case FieldTypeFloat64:
+		bl.StartVector(flatbuffers.SizeFloat64, l, flatbuffers.SizeFloat64)
+		for _, elem := range unsafe.Slice((*float64)(m.typedArr), l) {
+			bl.PrependFloat64(elem)
+		}
+	case FieldTypeBool:
+		bl.StartVector(flatbuffers.SizeBool, l, flatbuffers.SizeBool)
+		for _, elem := range unsafe.Slice((*bool)(m.typedArr), l) {
+			bl.PrependBool(elem)
+		}
+	case FieldTypeByte:
+		return bl.CreateByteVector(unsafe.Slice((*byte)(m.typedArr), l))
+	default: // string
+		stringUOffsetTs := getUOffsetSlice(l)
+		for i, str := range unsafe.Slice((*string)(m.typedArr), l) {
+			(*stringUOffsetTs)[i] = bl.CreateString(str)
+		}
+		bl.StartVector(flatbuffers.SizeUOffsetT, l, flatbuffers.SizeUOffsetT)
+		for i := 0; i < l; i++ {
+			bl.PrependUOffsetT((*stringUOffsetTs)[i])
+		}
+		putUOffsetSlice(stringUOffsetTs)
+	}
+	return bl.EndVector(l)
+}
+
 // IsNil returns if current buffer means nothing
 // need to comply to gojay.MarshalerJSONObject
 func (b *Buffer) IsNil() bool {
@@ -1889,13 +2711,21 @@ func (b *Buffer) IsNil() bool {
 }
 
 // MarshalJSONObject encodes current Buffer into JSON using gojay. Complies to gojay.MarshalerJSONObject interface
+// Sensitive fields are rendered according to the policy set by SetRedactionPolicy()
 func (b *Buffer) MarshalJSONObject(enc *gojay.Encoder) {
+	b.marshalJSONObject(enc, b.redaction)
+}
+
+// nil policy -> values are written as is
+func (b *Buffer) marshalJSONObject(enc *gojay.Encoder, policy *RedactionPolicy) {
 	b.prepareFieldsToBytes()
 	for _, f := range b.Scheme.Fields {
 		var value interface{}
 		fieldToBytes := &b.fieldsToBytes[f.Order]
-		if fieldToBytes.hasValue {
-			value = fieldToBytes.value
+		if len(fieldToBytes.arrayOps) > 0 {
+			value, _ = b.getArrayWithOps(f, fieldToBytes) // error -> nil, the field is skipped
+		} else if fieldToBytes.hasValue {
+			value = fieldToBytes.getValue()
 		} else {
 			if f.IsArray {
 				value = b.getArrIntf(f)
@@ -1912,20 +2742,26 @@ func (b *Buffer) MarshalJSONObject(enc *gojay.Encoder) {
 				continue
 			}
 		}
+		if action := policy.action(f); action != RedactionShow {
+			if action != RedactionOmit && !isEmptyJSONValue(f, value) {
+				encodeRedacted(enc, f.Name, policy.redact(f, action, value))
+			}
+			continue
+		}
 		if f.Ft == FieldTypeObject {
 			if f.IsArray {
 				switch arr := value.(type) {
 				case *ObjectArray:
 					enc.AddArrayKey(f.Name, gojay.EncodeArrayFunc(func(enc *gojay.Encoder) {
 						for arr.Next() {
-							enc.AddObject(arr.Buffer)
+							enc.AddObject(jsonObject(arr.Buffer, policy))
 						}
 					}))
 				case *buffersSlice:
 					enc.AddArrayKey(f.Name, gojay.EncodeArrayFunc(func(enc *gojay.Encoder) {
 						for _, buffer := range arr.Slice {
 							if buffer != nil {
-								enc.AddObject(buffer)
+								enc.AddObject(jsonObject(buffer, policy))
 							}
 						}
 					}))
@@ -1933,15 +2769,22 @@ func (b *Buffer) MarshalJSONObject(enc *gojay.Encoder) {
 					enc.AddArrayKey(f.Name, gojay.EncodeArrayFunc(func(enc *gojay.Encoder) {
 						for _, buffer := range arr {
 							if buffer != nil {
-								enc.AddObject(buffer)
+								enc.AddObject(jsonObject(buffer, policy))
 							}
 						}
 					}))
+				case *MutableObjectArray:
+					enc.AddArrayKey(f.Name, gojay.EncodeArrayFunc(func(enc *gojay.Encoder) {
+						_ = arr.iterate(func(elem *Buffer) error {
+							enc.AddObject(jsonObject(elem, policy))
+							return nil
+						})
+					}))
 				}
 			} else {
 				b := value.(*Buffer)
 				if !b.IsNil() {
-					enc.AddObjectKey(f.Name, b)
+					enc.AddObjectKey(f.Name, jsonObject(b, policy))
 				}
 			}
 		} else {
@@ -2038,12 +2881,17 @@ func (b *Buffer) MarshalJSONObject(enc *gojay.Encoder) {
 }
 
 // ToJSON returns JSON key->value string
-// empty buffer -> "{}"
+// empty buffer -> "{}". Sensitive fields are rendered according to the policy set by SetRedactionPolicy()
 func (b *Buffer) ToJSON() []byte {
+	return b.toJSON(b.redaction)
+}
+
+// nil policy -> values are written as is
+func (b *Buffer) toJSON(policy *RedactionPolicy) []byte {
 	buf := bytes.NewBuffer(nil)
 	enc := gojay.BorrowEncoder(buf)
 	defer enc.Release()
-	enc.EncodeObject(b) // nolint errcheck error impossible
+	enc.EncodeObject(jsonObject(b, policy)) // nolint errcheck error impossible
 	return buf.Bytes()
 }
 
@@ -2058,27 +2906,41 @@ func (b *Buffer) GetBytes() []byte {
 // numeric field types are kept (not float64 as json.Unmarshal() does)
 // nested object, array, array element is empty or nil -> skip
 // empty buffer -> empty map is returned
+// Sensitive fields are rendered according to the policy set by SetRedactionPolicy()
 func (b *Buffer) ToJSONMap() map[string]interface{} {
+	return b.toJSONMap(b.redaction)
+}
+
+// nil policy -> values are returned as is
+func (b *Buffer) toJSONMap(policy *RedactionPolicy) map[string]interface{} {
 	res := map[string]interface{}{}
 	b.prepareFieldsToBytes()
 	for _, f := range b.Scheme.Fields {
 		var storedVal interface{}
 		fieldToBytes := &b.fieldsToBytes[f.Order]
-		if fieldToBytes.hasValue {
-			storedVal = fieldToBytes.value
+		if len(fieldToBytes.arrayOps) > 0 {
+			storedVal, _ = b.getArrayWithOps(f, fieldToBytes) // error -> nil, the field is skipped
+		} else if fieldToBytes.hasValue {
+			storedVal = fieldToBytes.getValue()
 		} else {
 			storedVal = b.getByField(f)
 		}
 		if storedVal == nil {
 			continue
 		}
+		if action := policy.action(f); action != RedactionShow {
+			if action != RedactionOmit && !isEmptyJSONValue(f, storedVal) {
+				res[f.Name] = policy.redact(f, action, storedVal)
+			}
+			continue
+		}
 		if f.Ft == FieldTypeObject {
 			if f.IsArray {
 				targetArr := []interface{}{}
 				switch arr := storedVal.(type) {
 				case *ObjectArray:
 					for arr.Next() {
-						if elem := arr.Buffer.ToJSONMap(); len(elem) > 0 {
+						if elem := arr.Buffer.toJSONMap(policy); len(elem) > 0 {
 							targetArr = append(targetArr, elem)
 						}
 					}
@@ -2086,23 +2948,30 @@ func (b *Buffer) ToJSONMap() map[string]interface{} {
 					// came on ApplyMap()
 					buffers, _ := storedVal.(*buffersSlice)
 					for _, buffer := range buffers.Slice {
-						if elem := buffer.ToJSONMap(); len(elem) > 0 {
+						if elem := buffer.toJSONMap(policy); len(elem) > 0 {
 							targetArr = append(targetArr, elem)
 						}
 					}
 				case []*Buffer:
 					// explicit Set() was called
 					for _, buffer := range arr {
-						if elem := buffer.ToJSONMap(); len(elem) > 0 {
+						if elem := buffer.toJSONMap(policy); len(elem) > 0 {
 							targetArr = append(targetArr, elem)
 						}
 					}
+				case *MutableObjectArray:
+					_ = arr.iterate(func(buffer *Buffer) error {
+						if elem := buffer.toJSONMap(policy); len(elem) > 0 {
+							targetArr = append(targetArr, elem)
+						}
+						return nil
+					})
 				}
 				if len(targetArr) > 0 {
 					res[f.Name] = targetArr
 				}
 			} else {
-				if nested := storedVal.(*Buffer).ToJSONMap(); len(nested) > 0 {
+				if nested := storedVal.(*Buffer).toJSONMap(policy); len(nested) > 0 {
 					res[f.Name] = nested
 				}
 			}
@@ -2129,7 +2998,12 @@ func (b *Buffer) ToJSONMap() map[string]interface{} {
 // `names` empty -> callback is called for all fields which has a value
 // `names` not empty -> callback is called for each specified name if according field has a value
 // callbeck returns false -> iteration stops
+// Policy is set by SetRedactionPolicy() -> values are provided as RedactedBuffer.IterateFields() provides them
 func (b *Buffer) IterateFields(names []string, callback func(name string, value interface{}) bool) {
+	if b.redaction != nil {
+		b.Redacted(b.redaction).IterateFields(names, callback)
+		return
+	}
 	if len(b.tab.Bytes) == 0 {
 		return
 	}
@@ -2154,7 +3028,7 @@ func (b *Buffer) IterateFields(names []string, callback func(name string, value
 
 func (b *Buffer) IsModified() bool {
 	for _, fieldToBytes := range b.fieldsToBytes {
-		if fieldToBytes.hasValue {
+		if fieldToBytes.hasValue || len(fieldToBytes.arrayOps) > 0 {
 			return true
 		}
 	}
@@ -2204,7 +3078,7 @@ func (s *Scheme) AddNestedArray(name string, nested *Scheme, isMandatory bool) *
 
 // AddFieldC adds new finely-tuned field
 func (s *Scheme) AddFieldC(name string, ft FieldType, nested *Scheme, isMandatory bool, isArray bool) *Scheme {
-	newField := &Field{name, ft, len(s.FieldsMap), isMandatory, nested, s, isArray}
+	newField := &Field{name, ft, len(s.FieldsMap), isMandatory, nested, s, isArray, "", nil}
 	s.FieldsMap[name] = newField
 	s.Fields = append(s.Fields, newField)
 	return s
@@ -2225,6 +3099,9 @@ func (s *Scheme) MarshalYAML() (interface{}, error) {
 				if f.IsArray {
 					fieldName += ".."
 				}
+				if len(f.Sensitivity) > 0 {
+					fieldName += "@" + f.Sensitivity
+				}
 				var val interface{}
 				if f.Ft == FieldTypeObject {
 					val, _ = f.FieldScheme.MarshalYAML() // no errors possible
@@ -2252,9 +3129,19 @@ func (s *Scheme) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	}
 	s.Fields = newS.Fields
 	s.FieldsMap = newS.FieldsMap
+	for _, f := range s.Fields {
+		f.ownerScheme = s
+	}
 	return nil
 }
 
+// Field returns the field by name or nil if the Scheme has no such field
+// The result is useful for *F() methods of Buffer (GetInt64F(), SetF() etc) which do not spend time on search by name. They behave the same as
+// according methods accepting the field name but panic if the Field does not belong to the Buffer's Scheme
+func (s *Scheme) Field(name string) *Field {
+	return s.FieldsMap[name]
+}
+
 // GetNestedScheme returns Scheme of nested object if the field has FieldTypeObject type, nil otherwise
 func (s *Scheme) GetNestedScheme(nestedObjectField string) *Scheme {
 	if f, ok := s.FieldsMap[nestedObjectField]; ok {
@@ -2284,6 +3171,7 @@ func (f *Field) QualifiedName() string {
 //
 // Field name starts with the capital letter -> field is mandatory
 // Field name ends with `..` -> field is an array
+// Field name ends with `@label` -> field has sensitivity label, e.g. `cardNo@card: string`, `phones..@pii: string`. See RedactionPolicy
 // See [dynobuffers_test.go](dynobuffers_test.go) for examples
 func YamlToScheme(yamlStr string) (*Scheme, error) {
 	mapSlice := yaml.MapSlice{}
@@ -2298,7 +3186,7 @@ func MapSliceToScheme(mapSlice yaml.MapSlice) (*Scheme, error) {
 	res := NewScheme()
 	for _, mapItem := range mapSlice {
 		if nestedMapSlice, ok := mapItem.Value.(yaml.MapSlice); ok {
-			fieldName, isMandatory, IsArray := fieldPropsFromYaml(mapItem.Key.(string))
+			fieldName, isMandatory, IsArray, sensitivity := fieldPropsFromYaml(mapItem.Key.(string))
 			nestedScheme, err := MapSliceToScheme(nestedMapSlice)
 			if err != nil {
 				return nil, err
@@ -2309,14 +3197,16 @@ func MapSliceToScheme(mapSlice yaml.MapSlice) (*Scheme, error) {
 			} else {
 				res.AddNested(fieldName, nestedScheme, isMandatory)
 			}
+			res.FieldsMap[fieldName].Sensitivity = sensitivity
 		} else if typeStr, ok := mapItem.Value.(string); ok {
-			fieldName, isMandatory, IsArray := fieldPropsFromYaml(mapItem.Key.(string))
+			fieldName, isMandatory, IsArray, sensitivity := fieldPropsFromYaml(mapItem.Key.(string))
 			if ft, ok := yamlFieldTypesMap[typeStr]; ok {
 				if IsArray {
 					res.AddArray(fieldName, ft, isMandatory)
 				} else {
 					res.AddField(fieldName, ft, isMandatory)
 				}
+				res.FieldsMap[fieldName].Sensitivity = sensitivity
 			} else {
 				return nil, errors.New("unknown field type: " + typeStr)
 			}
@@ -2326,9 +3216,14 @@ func MapSliceToScheme(mapSlice yaml.MapSlice) (*Scheme, error) {
 	return res, nil
 }
 
-func fieldPropsFromYaml(yamlStr string) (fieldName string, isMandatory bool, isArray bool) {
+func fieldPropsFromYaml(yamlStr string) (fieldName string, isMandatory bool, isArray bool, sensitivity string) {
 	isMandatory = unicode.IsUpper(rune(yamlStr[0]))
 
+	if i := strings.LastIndexByte(yamlStr, '@'); i > 0 {
+		sensitivity = yamlStr[i+1:]
+		yamlStr = yamlStr[:i]
+	}
+
 	isArray = strings.HasSuffix(yamlStr, "..")
 	if isArray {
 		yamlStr = yamlStr[:len(yamlStr)-2]
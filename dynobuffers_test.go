@@ -2471,6 +2471,310 @@ func TestIterateFields(t *testing.T) {
 	require.Zero(GetObjectsInUse())
 }
 
+func TestTypedSetters(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(allTypesYaml)
+	require.NoError(err)
+	sArrs, err := YamlToScheme(arraysAllTypesYaml)
+	require.NoError(err)
+
+	t.Run("scalars", func(t *testing.T) {
+		b := NewBuffer(s)
+		b.SetInt16("smallint", 1)
+		b.SetInt32("int", 2)
+		b.SetInt64("long", 3)
+		b.SetFloat32("float", 4)
+		b.SetFloat64("double", 5)
+		b.SetString("string", "str")
+		b.SetBool("boolTrue", true)
+		b.SetBool("boolFalse", false)
+		b.SetByte("byte", 6)
+		b.SetInt64("unknown", 7) // nothing happens
+		require.JSONEq(`{"smallint":1,"int":2,"long":3,"float":4,"double":5,"string":"str","boolTrue":true,"boolFalse":false,"byte":6}`, string(b.ToJSON()))
+		require.Equal(map[string]interface{}{"smallint": int16(1), "int": int32(2), "long": int64(3), "float": float32(4), "double": float64(5),
+			"string": "str", "boolTrue": true, "boolFalse": false, "byte": byte(6)}, b.ToJSONMap())
+		require.NoError(b.CommitChanges())
+		testFieldValues(t, b, int16(1), int32(2), int64(3), float32(4), float64(5), "str", true, false, byte(6))
+
+		// typed value rewrites the previous Set() and vice versa
+		b.Set("long", int64(8))
+		b.SetInt64("long", 9)
+		b.SetInt32("int", 10)
+		b.Set("int", int32(11))
+		b.SetString("string", "") // empty string -> unset
+		bytes, nilled, err := b.ToBytesNilled()
+		require.NoError(err)
+		require.Equal([]string{"string"}, nilled)
+		b.Reset(copyBytes(bytes))
+		testFieldValues(t, b, int16(1), int32(11), int64(9), float32(4), float64(5), nil, true, false, byte(6))
+		b.Release()
+	})
+
+	t.Run("arrays", func(t *testing.T) {
+		b := NewBuffer(sArrs)
+		b.SetInt16Array("smallints", []int16{1, 2})
+		b.SetInt32Array("ints", []int32{3, 4})
+		b.SetInt64Array("longs", []int64{5, 6})
+		b.SetFloat32Array("floats", []float32{7, 8})
+		b.SetFloat64Array("doubles", []float64{9, 10})
+		b.SetStringArray("strings", []string{"str1", "str2"})
+		b.SetBoolArray("boolTrues", []bool{true, false})
+		b.SetByteArray("bytes", []byte{11, 12})
+		b.SetBoolArray("boolFalses", nil) // nil -> unset
+		require.JSONEq(`{"smallints":[1,2],"ints":[3,4],"longs":[5,6],"floats":[7,8],"doubles":[9,10],"strings":["str1","str2"],"boolTrues":[true,false],"bytes":"Cww="}`, string(b.ToJSON()))
+		require.NoError(b.CommitChanges())
+		require.Equal([]int16{1, 2}, b.Get("smallints"))
+		require.Equal([]int32{3, 4}, b.Get("ints"))
+		require.Equal([]int64{5, 6}, b.Get("longs"))
+		require.Equal([]float32{7, 8}, b.Get("floats"))
+		require.Equal([]float64{9, 10}, b.Get("doubles"))
+		require.Equal([]string{"str1", "str2"}, b.Get("strings"))
+		require.Equal([]bool{true, false}, b.Get("boolTrues"))
+		require.Equal([]byte{11, 12}, b.Get("bytes"))
+		require.Nil(b.Get("boolFalses"))
+
+		// typed array appended after Append() -> previous modification is rewritten
+		b.Append("longs", []int64{7})
+		b.SetInt64Array("longs", []int64{8})
+		require.NoError(b.CommitChanges())
+		require.Equal([]int64{8}, b.Get("longs"))
+		b.Release()
+	})
+
+	t.Run("wrong types", func(t *testing.T) {
+		b := NewBuffer(s)
+		b.SetInt64("int", 1)
+		_, err := b.ToBytes()
+		require.Error(err)
+
+		b.Set("int", nil)
+		b.SetString("long", "str")
+		_, err = b.ToBytes()
+		require.Error(err)
+
+		b.Set("long", nil)
+		b.SetInt32Array("int", []int32{1})
+		_, err = b.ToBytes()
+		require.Error(err)
+		b.Release()
+
+		b = NewBuffer(sArrs)
+		b.SetInt64Array("ints", []int64{1})
+		_, err = b.ToBytes()
+		require.Error(err)
+
+		b.Set("ints", nil)
+		b.SetInt32("ints", 1)
+		_, err = b.ToBytes()
+		require.Error(err)
+
+		b.Set("ints", nil)
+		b.SetInt32("intsObj", 1)
+		_, err = b.ToBytes()
+		require.Error(err)
+		b.Release()
+
+		// compatible value is converted by Set() rules
+		b = NewBuffer(s)
+		b.SetFloat64("int", 5)
+		require.NoError(b.CommitChanges())
+		require.Equal(int32(5), b.Get("int"))
+		b.SetFloat64("int", 5.5)
+		_, err = b.ToBytes()
+		require.Error(err)
+		b.Release()
+	})
+
+	t.Run("no allocations", func(t *testing.T) {
+		b := NewBuffer(s)
+		bArrs := NewBuffer(sArrs)
+		longs := []int64{1, 2}
+		strs := []string{"str1", "str2"}
+		str := "str"
+		allocs := testing.AllocsPerRun(100, func() {
+			b.SetInt16("smallint", 1)
+			b.SetInt32("int", 2)
+			b.SetInt64("long", 3)
+			b.SetFloat32("float", 4)
+			b.SetFloat64("double", 5)
+			b.SetString("string", str)
+			b.SetBool("boolTrue", true)
+			b.SetByte("byte", 6)
+			if _, err := b.ToBytes(); err != nil {
+				t.Fatal(err)
+			}
+			bArrs.SetInt64Array("longs", longs)
+			bArrs.SetStringArray("strings", strs)
+			if _, err := bArrs.ToBytes(); err != nil {
+				t.Fatal(err)
+			}
+		})
+		require.Zero(allocs)
+		b.Release()
+		bArrs.Release()
+	})
+
+	require.Zero(GetObjectsInUse())
+}
+
+func TestFieldHandles(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(allTypesYaml)
+	require.NoError(err)
+	sArrs, err := YamlToScheme(arraysAllTypesYaml)
+	require.NoError(err)
+
+	require.Nil(s.Field("unknown"))
+	fSmallint := s.Field("smallint")
+	fInt := s.Field("int")
+	fLong := s.Field("long")
+	fFloat := s.Field("float")
+	fDouble := s.Field("double")
+	fString := s.Field("string")
+	fBoolTrue := s.Field("boolTrue")
+	fBoolFalse := s.Field("boolFalse")
+	fByte := s.Field("byte")
+	require.Same(s.FieldsMap["long"], fLong)
+
+	b := NewBuffer(s)
+	b.SetInt16F(fSmallint, 1)
+	b.SetInt32F(fInt, 2)
+	b.SetInt64F(fLong, 3)
+	b.SetFloat32F(fFloat, 4)
+	b.SetF(fDouble, float64(5))
+	b.SetStringF(fString, "str")
+	b.SetBoolF(fBoolTrue, true)
+	b.SetBoolF(fBoolFalse, false)
+	b.SetByteF(fByte, 6)
+	require.NoError(b.CommitChanges())
+	testFieldValues(t, b, int16(1), int32(2), int64(3), float32(4), float64(5), "str", true, false, byte(6))
+
+	smallint, ok := b.GetInt16F(fSmallint)
+	require.True(ok)
+	require.Equal(int16(1), smallint)
+	i, ok := b.GetInt32F(fInt)
+	require.True(ok)
+	require.Equal(int32(2), i)
+	long, ok := b.GetInt64F(fLong)
+	require.True(ok)
+	require.Equal(int64(3), long)
+	float, ok := b.GetFloat32F(fFloat)
+	require.True(ok)
+	require.Equal(float32(4), float)
+	double, ok := b.GetFloat64F(fDouble)
+	require.True(ok)
+	require.Equal(float64(5), double)
+	str, ok := b.GetStringF(fString)
+	require.True(ok)
+	require.Equal("str", str)
+	boolTrue, ok := b.GetBoolF(fBoolTrue)
+	require.True(ok)
+	require.True(boolTrue)
+	by, ok := b.GetByteF(fByte)
+	require.True(ok)
+	require.Equal(byte(6), by)
+	require.True(b.HasValueF(fLong))
+
+	b.SetF(fLong, nil)
+	require.NoError(b.CommitChanges())
+	_, ok = b.GetInt64F(fLong)
+	require.False(ok)
+	require.False(b.HasValueF(fLong))
+	b.Release()
+
+	bArrs := NewBuffer(sArrs)
+	bArrs.SetInt16ArrayF(sArrs.Field("smallints"), []int16{1, 2})
+	bArrs.SetInt32ArrayF(sArrs.Field("ints"), []int32{3})
+	bArrs.SetInt64ArrayF(sArrs.Field("longs"), []int64{4})
+	bArrs.SetFloat32ArrayF(sArrs.Field("floats"), []float32{5})
+	bArrs.SetFloat64ArrayF(sArrs.Field("doubles"), []float64{6})
+	bArrs.SetStringArrayF(sArrs.Field("strings"), []string{"str"})
+	bArrs.SetBoolArrayF(sArrs.Field("boolTrues"), []bool{true})
+	bArrs.SetByteArrayF(sArrs.Field("bytes"), []byte{7})
+	require.NoError(bArrs.CommitChanges())
+	smallints := bArrs.GetInt16ArrayF(sArrs.Field("smallints"))
+	require.Equal(2, smallints.Len())
+	require.Equal(int16(2), smallints.At(1))
+	require.Equal(int32(3), bArrs.GetInt32ArrayF(sArrs.Field("ints")).At(0))
+	require.Equal(int64(4), bArrs.GetInt64ArrayF(sArrs.Field("longs")).At(0))
+	require.Equal(float32(5), bArrs.GetFloat32ArrayF(sArrs.Field("floats")).At(0))
+	require.Equal(float64(6), bArrs.GetFloat64ArrayF(sArrs.Field("doubles")).At(0))
+	require.Equal("str", bArrs.GetStringArrayF(sArrs.Field("strings")).At(0))
+	require.True(bArrs.GetBoolArrayF(sArrs.Field("boolTrues")).At(0))
+	require.Equal([]byte{7}, bArrs.GetByteArrayF(sArrs.Field("bytes")).Bytes())
+	require.Nil(bArrs.GetBoolArrayF(sArrs.Field("boolFalses")))
+
+	// Field of an other Scheme -> panic
+	require.Panics(func() { bArrs.GetInt64F(fLong) })
+	require.Panics(func() { bArrs.SetF(fLong, int64(1)) })
+	require.Panics(func() { bArrs.GetInt64ArrayF(fLong) })
+	require.Panics(func() { bArrs.GetInt64F(nil) })
+	bArrs.Release()
+
+	// Scheme unmarshaled from yaml owns its fields
+	sYaml := NewScheme()
+	require.NoError(yaml.Unmarshal([]byte(allTypesYaml), sYaml))
+	b = NewBuffer(sYaml)
+	require.NotPanics(func() { b.SetInt64F(sYaml.Field("long"), 1) })
+	b.Release()
+
+	require.Zero(GetObjectsInUse())
+}
+
+func TestClone(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(orderSchemeYaml)
+	require.NoError(err)
+	b := ReadBuffer(getOrderBytes(t, s), s)
+
+	// pending modifications are considered
+	b.Set("name", "order2")
+	clone, err := b.Clone()
+	require.NoError(err)
+	b.Release()
+	require.Equal("order2", clone.Get("name"))
+	require.Equal([]string{"vip", "delivery"}, clone.Get("tags"))
+	require.False(clone.IsModified())
+
+	// nested object is cloned as a root
+	article, err := clone.Get("article").(*Buffer).Clone()
+	require.NoError(err)
+	require.Equal(int64(1), article.Get("id"))
+
+	// array elements
+	lines := clone.Get("lines").(*ObjectArray)
+	require.Panics(func() { lines.Detach() })
+	require.True(lines.Next())
+	line0 := lines.Detach()
+	line1 := lines.CloneAt(1)
+	require.Panics(func() { lines.CloneAt(2) })
+	require.True(lines.Next()) // iteration state is not changed
+	require.Equal(int32(2), lines.Buffer.Get("qty"))
+	clone.Release()
+
+	require.Equal(int32(1), line0.Get("qty"))
+	require.Equal("art10", line0.Get("article").(*Buffer).Get("name"))
+	require.Equal(int32(2), line1.Get("qty"))
+	line0.Release()
+	line1.Release()
+	article.Release()
+
+	// empty
+	b = NewBuffer(s)
+	clone, err = b.Clone()
+	require.NoError(err)
+	require.True(clone.IsNil())
+	clone.Release()
+
+	// error
+	b.Set("name", 42)
+	_, err = b.Clone()
+	require.Error(err)
+	b.Release()
+
+	require.Zero(GetObjectsInUse())
+}
+
 func TestGetNestedScheme(t *testing.T) {
 	require := require.New(t)
 	bNested := NewScheme()
@@ -2509,6 +2813,53 @@ func TestPreviousResultDamageOnReuse(t *testing.T) {
 	require.Zero(GetObjectsInUse())
 }
 
+func TestToBytesPooled(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(schemeStr)
+	require.NoError(err)
+	b := NewBuffer(s)
+	b.Set("name", "str")
+	b.Set("quantity", 42)
+	res1, err := b.ToBytesPooled()
+	require.NoError(err)
+	bytes1Copy := copyBytes(res1.Bytes())
+
+	b.Release()
+	b = NewBuffer(s)
+	b.Set("name", "str")
+	b.Set("quantity", 43)
+	res2, err := b.ToBytesPooled()
+	require.NoError(err)
+	_, err = b.ToBytes()
+	require.NoError(err)
+
+	// both results are kept
+	require.Equal(bytes1Copy, res1.Bytes())
+	bRead := ReadBuffer(res2.Bytes(), s)
+	require.Equal(int32(43), bRead.Get("quantity"))
+	bRead.Release()
+	res1.Release()
+	res1.Release() // second release is ok
+	res2.Release()
+
+	// nothing to encode
+	b.Release()
+	b = NewBuffer(s)
+	res, err := b.ToBytesPooled()
+	require.NoError(err)
+	require.Nil(res.Bytes())
+	res.Release()
+
+	// error
+	b.Set("quantity", "str")
+	res, err = b.ToBytesPooled()
+	require.Error(err)
+	require.Nil(res)
+
+	b.Release()
+	require.Zero(GetObjectsInUse())
+}
+
 func TestRelease(t *testing.T) {
 	require := require.New(t)
 	s, err := YamlToScheme(allTypesYaml)
@@ -23,6 +23,10 @@ var (
 	uOffsetsInUse     uint64
 	offsetsInUse      uint64
 	objectArraysInUse uint64
+
+	mutableObjectArraysInUse uint64
+	pooledBytesInUse         uint64
+	decompressedBytesInUse   uint64
 )
 
 type offset struct {
@@ -45,6 +49,15 @@ var (
 	objectArrayPool = sync.Pool{
 		New: func() interface{} { return &ObjectArray{} },
 	}
+	mutableObjectArrayPool = sync.Pool{
+		New: func() interface{} { return &MutableObjectArray{} },
+	}
+	pooledBytesPool = sync.Pool{
+		New: func() interface{} { return &pooledBytes{builder: flatbuffers.NewBuilder(0)} },
+	}
+	decompressedBytesPool = sync.Pool{
+		New: func() interface{} { return &decompressedBytes{} },
+	}
 	uOffsetPool = sync.Pool{
 		New: func() interface{} {
 			res := make([]flatbuffers.UOffsetT, defaultBufferSize)
@@ -193,6 +206,75 @@ func putObjectArray(oa *ObjectArray) {
 	atomic.AddUint64(&objectArraysInUse, ^uint64(0))
 }
 
+func getMutableObjectArray() *MutableObjectArray {
+	res := mutableObjectArrayPool.Get().(*MutableObjectArray)
+	res.isReleased = false
+	atomic.AddUint64(&mutableObjectArraysInUse, 1)
+	return res
+}
+
+func putMutableObjectArray(a *MutableObjectArray) {
+	mutableObjectArrayPool.Put(a)
+	atomic.AddUint64(&mutableObjectArraysInUse, ^uint64(0))
+}
+
+// pooledBytes is a result of ToBytesPooled()
+type pooledBytes struct {
+	builder    *flatbuffers.Builder
+	bytes      []byte
+	isReleased bool
+}
+
+func (pb *pooledBytes) Bytes() []byte {
+	return pb.bytes
+}
+
+func (pb *pooledBytes) Release() {
+	if pb.isReleased {
+		return
+	}
+	pb.bytes = nil
+	pb.isReleased = true
+	pooledBytesPool.Put(pb)
+	atomic.AddUint64(&pooledBytesInUse, ^uint64(0))
+}
+
+func getPooledBytes() *pooledBytes {
+	res := pooledBytesPool.Get().(*pooledBytes)
+	res.builder.Reset()
+	res.isReleased = false
+	atomic.AddUint64(&pooledBytesInUse, 1)
+	return res
+}
+
+// decompressedBytes are bytes of a Buffer read by ReadBufferCompressed(). Released on the Buffer release
+type decompressedBytes struct {
+	bytes      []byte
+	isReleased bool
+}
+
+func (db *decompressedBytes) Release() {
+	if db.isReleased {
+		return
+	}
+	db.isReleased = true
+	decompressedBytesPool.Put(db)
+	atomic.AddUint64(&decompressedBytesInUse, ^uint64(0))
+}
+
+// getDecompressedBytes returns decompressedBytes of `l` bytes reusing the pooled slice
+func getDecompressedBytes(l int) *decompressedBytes {
+	res := decompressedBytesPool.Get().(*decompressedBytes)
+	if cap(res.bytes) < l {
+		res.bytes = make([]byte, l)
+	} else {
+		res.bytes = res.bytes[:l]
+	}
+	res.isReleased = false
+	atomic.AddUint64(&decompressedBytesInUse, 1)
+	return res
+}
+
 // GetObjectsInUse returns pooled objects amount which are currently in use, i.e. not released
 // useful for testing and metrics accounting
 func GetObjectsInUse() uint64 {
@@ -200,5 +282,8 @@ func GetObjectsInUse() uint64 {
 		atomic.LoadUint64(&buffersInUse) +
 		atomic.LoadUint64(&offsetsInUse) +
 		atomic.LoadUint64(&uOffsetsInUse) +
-		atomic.LoadUint64(&objectArraysInUse)
+		atomic.LoadUint64(&objectArraysInUse) +
+		atomic.LoadUint64(&mutableObjectArraysInUse) +
+		atomic.LoadUint64(&pooledBytesInUse) +
+		atomic.LoadUint64(&decompressedBytesInUse)
 }
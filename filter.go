@@ -0,0 +1,698 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// Filter expression:
+//
+//	expr:       or
+//	or:         and {"or" and}
+//	and:        unary {"and" unary}
+//	unary:      "not" unary | "(" expr ")" | quantifier | predicate
+//	quantifier: ("any" | "all") "(" path "," expr ")"
+//	predicate:  operand ("==" | "!=" | "<" | "<=" | ">" | ">=") literal | operand "in" "(" literal {"," literal} ")" | operand "startsWith" string
+//	operand:    path | "it"
+//	path:       name {"." name}
+//	literal:    number | string | "true" | "false" | "null"
+//
+// Path goes through nested objects, e.g. `article.name`. Arrays are checked by quantifiers: expression of `any(lines, qty > 1)` is applied to each
+// element of the array of nested objects, `it` is the element of the array of scalars or strings, e.g. `any(tags, it == "vip")`
+// Unset value matches `== null` only. Comparison with `null` is allowed for any field, `<`, `<=`, `>`, `>=` are allowed for numbers and strings
+// Strings are double-quoted and compared byte-wise. `any` of an empty or unset array is false, `all` is true
+
+// ErrMalformedFilter is returned if a filter expression could not be parsed
+var ErrMalformedFilter = errors.New("malformed filter")
+
+// FilterError describes a failure to compile a filter expression. Err wraps ErrMalformedFilter, ErrUnknownField, ErrPathMismatch or ErrWrongValueType
+type FilterError struct {
+	Expr string
+	Pos  int
+	Err  error
+}
+
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("filter %s at %d: %v", e.Expr, e.Pos, e.Err)
+}
+
+func (e *FilterError) Unwrap() error {
+	return e.Err
+}
+
+// Filter is a predicate over Buffers of a Scheme. Compile once using Scheme.CompileFilter() and use many times
+// Evaluation reads stored bytes directly and does not allocate. Goroutine-safe
+type Filter struct {
+	scheme *Scheme
+	str    string
+	root   filterNode
+}
+
+// filterNode is evaluated over the table at `tab`. `it` is the position of the current element of an array of scalars or strings
+type filterNode interface {
+	eval(bytes []byte, tab flatbuffers.UOffsetT, it flatbuffers.UOffsetT) bool
+}
+
+type filterOp int
+
+const (
+	filterOpEq filterOp = iota
+	filterOpNe
+	filterOpLt
+	filterOpLe
+	filterOpGt
+	filterOpGe
+	filterOpIn
+	filterOpStartsWith
+	filterOpIsNull
+	filterOpIsNotNull
+)
+
+var filterOps = map[string]filterOp{
+	"==":         filterOpEq,
+	"!=":         filterOpNe,
+	"<":          filterOpLt,
+	"<=":         filterOpLe,
+	">":          filterOpGt,
+	">=":         filterOpGe,
+	"in":         filterOpIn,
+	"startsWith": filterOpStartsWith,
+}
+
+type filterLiteral struct {
+	i int64
+	f float64
+	s []byte
+	b bool
+}
+
+// filterPredicate compares the value by path or `it` with literals
+type filterPredicate struct {
+	steps    []flatbuffers.VOffsetT // slots of nested objects
+	slot     flatbuffers.VOffsetT   // 0 -> `it`
+	ft       FieldType
+	op       filterOp
+	literals []filterLiteral // one literal or a list for `in`
+}
+
+type filterAnd struct {
+	left, right filterNode
+}
+
+type filterOr struct {
+	left, right filterNode
+}
+
+type filterNot struct {
+	node filterNode
+}
+
+// filterQuantifier applies the node to each element of the array
+type filterQuantifier struct {
+	steps    []flatbuffers.VOffsetT
+	slot     flatbuffers.VOffsetT
+	isAll    bool
+	f        *Field
+	elemSize flatbuffers.UOffsetT
+	node     filterNode
+}
+
+// CompileFilter parses the expression and checks it against the Scheme. Error is *FilterError
+func (s *Scheme) CompileFilter(expr string) (*Filter, error) {
+	p := &filterParser{expr: expr}
+	p.next()
+	root, err := p.parseOr(s, FieldTypeUnspecified)
+	if err == nil && p.tok.kind != filterTokEOF {
+		err = p.error(ErrMalformedFilter, "unexpected %s", p.tok.str)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Filter{scheme: s, str: expr, root: root}, nil
+}
+
+// Match returns true if stored bytes of the Buffer match the filter. Modifications are not considered
+// The Buffer could be a nested object or an element of an array of nested objects, e.g. ObjectArray.Buffer
+// Panics if the Buffer's Scheme is not the Scheme of the Filter
+func (f *Filter) Match(b *Buffer) bool {
+	if b.Scheme != f.scheme {
+		panic("the Buffer's Scheme does not match the Filter's Scheme")
+	}
+	if len(b.tab.Bytes) == 0 {
+		return f.root.eval(nil, 0, 0)
+	}
+	return f.root.eval(b.tab.Bytes, b.tab.Pos, 0)
+}
+
+// MatchBytes returns true if the bytes of the Filter's Scheme match the filter
+func (f *Filter) MatchBytes(bytes []byte) bool {
+	if len(bytes) == 0 {
+		return f.root.eval(nil, 0, 0)
+	}
+	return f.root.eval(bytes, flatbuffers.GetUOffsetT(bytes), 0)
+}
+
+func (f *Filter) String() string {
+	return f.str
+}
+
+// filterResolve returns position of the field by path, 0 if the field or any nested object along the path is unset
+func filterResolve(bytes []byte, tab flatbuffers.UOffsetT, steps []flatbuffers.VOffsetT, slot flatbuffers.VOffsetT) flatbuffers.UOffsetT {
+	if len(bytes) == 0 {
+		return 0
+	}
+	for _, step := range steps {
+		pos := filterSlot(bytes, tab, step)
+		if pos == 0 {
+			return 0
+		}
+		tab = pos + flatbuffers.GetUOffsetT(bytes[pos:])
+	}
+	return filterSlot(bytes, tab, slot)
+}
+
+func filterSlot(bytes []byte, tab flatbuffers.UOffsetT, slot flatbuffers.VOffsetT) flatbuffers.UOffsetT {
+	vtable := flatbuffers.UOffsetT(flatbuffers.SOffsetT(tab) - flatbuffers.GetSOffsetT(bytes[tab:]))
+	if slot >= flatbuffers.GetVOffsetT(bytes[vtable:]) {
+		return 0
+	}
+	if vOffsetT := flatbuffers.GetVOffsetT(bytes[vtable+flatbuffers.UOffsetT(slot):]); vOffsetT != 0 {
+		return tab + flatbuffers.UOffsetT(vOffsetT)
+	}
+	return 0
+}
+
+func (n *filterPredicate) eval(data []byte, tab flatbuffers.UOffsetT, it flatbuffers.UOffsetT) bool {
+	pos := it
+	if n.slot != 0 {
+		pos = filterResolve(data, tab, n.steps, n.slot)
+	}
+	switch {
+	case n.op == filterOpIsNull:
+		return pos == 0
+	case n.op == filterOpIsNotNull:
+		return pos != 0
+	case pos == 0:
+		return false
+	}
+	switch n.ft {
+	case FieldTypeInt16, FieldTypeInt32, FieldTypeInt64, FieldTypeByte:
+		value := filterInt(data, pos, n.ft)
+		for i := range n.literals {
+			if n.op.matches(compareOrdered(value, n.literals[i].i)) {
+				return true
+			}
+		}
+	case FieldTypeFloat32, FieldTypeFloat64:
+		var value float64
+		if n.ft == FieldTypeFloat32 {
+			value = float64(flatbuffers.GetFloat32(data[pos:]))
+		} else {
+			value = flatbuffers.GetFloat64(data[pos:])
+		}
+		if math.IsNaN(value) {
+			// NaN is not equal, less or greater than anything
+			return n.op == filterOpNe
+		}
+		for i := range n.literals {
+			if n.op.matches(compareOrdered(value, n.literals[i].f)) {
+				return true
+			}
+		}
+	case FieldTypeBool:
+		value := flatbuffers.GetBool(data[pos:])
+		for i := range n.literals {
+			if n.op.matches(compareBools(value, n.literals[i].b)) {
+				return true
+			}
+		}
+	case FieldTypeString:
+		start := pos + flatbuffers.GetUOffsetT(data[pos:]) + flatbuffers.SizeUOffsetT
+		value := data[start : start+flatbuffers.GetUOffsetT(data[start-flatbuffers.SizeUOffsetT:])]
+		if n.op == filterOpStartsWith {
+			return bytes.HasPrefix(value, n.literals[0].s)
+		}
+		for i := range n.literals {
+			if n.op.matches(bytes.Compare(value, n.literals[i].s)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (op filterOp) matches(cmp int) bool {
+	switch op {
+	case filterOpEq, filterOpIn:
+		return cmp == 0
+	case filterOpNe:
+		return cmp != 0
+	case filterOpLt:
+		return cmp < 0
+	case filterOpLe:
+		return cmp <= 0
+	case filterOpGt:
+		return cmp > 0
+	default: // filterOpGe
+		return cmp >= 0
+	}
+}
+
+func filterInt(bytes []byte, pos flatbuffers.UOffsetT, ft FieldType) int64 {
+	switch ft {
+	case FieldTypeInt16:
+		return int64(flatbuffers.GetInt16(bytes[pos:]))
+	case FieldTypeInt32:
+		return int64(flatbuffers.GetInt32(bytes[pos:]))
+	case FieldTypeInt64:
+		return flatbuffers.GetInt64(bytes[pos:])
+	default: // byte
+		return int64(flatbuffers.GetByte(bytes[pos:]))
+	}
+}
+
+func compareOrdered[T int64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (n *filterAnd) eval(bytes []byte, tab flatbuffers.UOffsetT, it flatbuffers.UOffsetT) bool {
+	return n.left.eval(bytes, tab, it) && n.right.eval(bytes, tab, it)
+}
+
+func (n *filterOr) eval(bytes []byte, tab flatbuffers.UOffsetT, it flatbuffers.UOffsetT) bool {
+	return n.left.eval(bytes, tab, it) || n.right.eval(bytes, tab, it)
+}
+
+func (n *filterNot) eval(bytes []byte, tab flatbuffers.UOffsetT, it flatbuffers.UOffsetT) bool {
+	return !n.node.eval(bytes, tab, it)
+}
+
+func (n *filterQuantifier) eval(bytes []byte, tab flatbuffers.UOffsetT, it flatbuffers.UOffsetT) bool {
+	pos := filterResolve(bytes, tab, n.steps, n.slot)
+	if pos == 0 {
+		return n.isAll
+	}
+	vector := pos + flatbuffers.GetUOffsetT(bytes[pos:])
+	l := flatbuffers.GetUOffsetT(bytes[vector:])
+	for i := flatbuffers.UOffsetT(0); i < l; i++ {
+		elemPos := vector + flatbuffers.SizeUOffsetT + i*n.elemSize
+		var res bool
+		if n.f.Ft == FieldTypeObject {
+			res = n.node.eval(bytes, elemPos+flatbuffers.GetUOffsetT(bytes[elemPos:]), 0)
+		} else {
+			res = n.node.eval(bytes, tab, elemPos)
+		}
+		if res != n.isAll {
+			return res
+		}
+	}
+	return n.isAll
+}
+
+type filterTokKind int
+
+const (
+	filterTokEOF filterTokKind = iota
+	filterTokName
+	filterTokString
+	filterTokNumber
+	filterTokOp
+	filterTokPunct // ( ) , .
+)
+
+type filterTok struct {
+	kind filterTokKind
+	str  string
+	pos  int
+}
+
+type filterParser struct {
+	expr string
+	pos  int
+	tok  filterTok
+	err  error
+}
+
+func (p *filterParser) error(err error, format string, args ...interface{}) error {
+	return &FilterError{Expr: p.expr, Pos: p.tok.pos, Err: fmt.Errorf("%w: "+format, append([]interface{}{err}, args...)...)}
+}
+
+func isFilterNameChar(c byte, isFirst bool) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (!isFirst && c >= '0' && c <= '9')
+}
+
+// next reads the next token. Lexical error -> p.err is set and EOF token is returned
+func (p *filterParser) next() {
+	for p.pos < len(p.expr) && strings.IndexByte(" \t\r\n", p.expr[p.pos]) >= 0 {
+		p.pos++
+	}
+	start := p.pos
+	p.tok = filterTok{pos: start}
+	if p.pos == len(p.expr) {
+		return
+	}
+	c := p.expr[p.pos]
+	switch {
+	case isFilterNameChar(c, true):
+		for p.pos < len(p.expr) && isFilterNameChar(p.expr[p.pos], false) {
+			p.pos++
+		}
+		p.tok.kind = filterTokName
+	case c == '"':
+		p.pos++
+		for p.pos < len(p.expr) && p.expr[p.pos] != '"' {
+			if p.expr[p.pos] == '\\' {
+				p.pos++
+			}
+			p.pos++
+		}
+		if p.pos >= len(p.expr) {
+			p.err = p.error(ErrMalformedFilter, "unterminated string")
+			return
+		}
+		p.pos++
+		p.tok.kind = filterTokString
+	case c == '-' || (c >= '0' && c <= '9'):
+		p.pos++
+		for p.pos < len(p.expr) && strings.IndexByte("0123456789.eE+-", p.expr[p.pos]) >= 0 {
+			if (p.expr[p.pos] == '+' || p.expr[p.pos] == '-') && p.expr[p.pos-1] != 'e' && p.expr[p.pos-1] != 'E' {
+				break
+			}
+			p.pos++
+		}
+		p.tok.kind = filterTokNumber
+	case strings.IndexByte("(),.", c) >= 0:
+		p.pos++
+		p.tok.kind = filterTokPunct
+	case strings.IndexByte("=!<>", c) >= 0:
+		p.pos++
+		if p.pos < len(p.expr) && p.expr[p.pos] == '=' {
+			p.pos++
+		}
+		p.tok.kind = filterTokOp
+	default:
+		p.err = p.error(ErrMalformedFilter, "unexpected character %q", c)
+		return
+	}
+	p.tok.str = p.expr[start:p.pos]
+}
+
+func (p *filterParser) isName(name string) bool {
+	return p.tok.kind == filterTokName && p.tok.str == name
+}
+
+func (p *filterParser) isPunct(punct string) bool {
+	return p.tok.kind == filterTokPunct && p.tok.str == punct
+}
+
+func (p *filterParser) expectPunct(punct string) error {
+	if p.err != nil {
+		return p.err
+	}
+	if !p.isPunct(punct) {
+		return p.error(ErrMalformedFilter, "%s expected", punct)
+	}
+	p.next()
+	return nil
+}
+
+// itFt is the type of the element of the array of scalars or strings which is `it`, FieldTypeUnspecified -> `it` is not available
+func (p *filterParser) parseOr(s *Scheme, itFt FieldType) (filterNode, error) {
+	left, err := p.parseAnd(s, itFt)
+	for err == nil && p.isName("or") {
+		p.next()
+		var right filterNode
+		if right, err = p.parseAnd(s, itFt); err == nil {
+			left = &filterOr{left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *filterParser) parseAnd(s *Scheme, itFt FieldType) (filterNode, error) {
+	left, err := p.parseUnary(s, itFt)
+	for err == nil && p.isName("and") {
+		p.next()
+		var right filterNode
+		if right, err = p.parseUnary(s, itFt); err == nil {
+			left = &filterAnd{left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *filterParser) parseUnary(s *Scheme, itFt FieldType) (filterNode, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	switch {
+	case p.isName("not"):
+		p.next()
+		node, err := p.parseUnary(s, itFt)
+		if err != nil {
+			return nil, err
+		}
+		return &filterNot{node: node}, nil
+	case p.isPunct("("):
+		p.next()
+		node, err := p.parseOr(s, itFt)
+		if err != nil {
+			return nil, err
+		}
+		return node, p.expectPunct(")")
+	case p.isName("any"), p.isName("all"):
+		return p.parseQuantifier(s)
+	case p.tok.kind == filterTokName:
+		return p.parsePredicate(s, itFt)
+	}
+	return nil, p.error(ErrMalformedFilter, "unexpected %q", p.tok.str)
+}
+
+func (p *filterParser) parseQuantifier(s *Scheme) (filterNode, error) {
+	res := &filterQuantifier{isAll: p.tok.str == "all"}
+	p.next()
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+	var err error
+	if res.steps, res.f, err = p.parsePath(s); err != nil {
+		return nil, err
+	}
+	if !res.f.IsArray {
+		return nil, p.error(ErrPathMismatch, "%s is not an array", res.f.QualifiedName())
+	}
+	res.slot = flatbuffers.VOffsetT((res.f.Order + 2) * 2)
+	if err := p.expectPunct(","); err != nil {
+		return nil, err
+	}
+	if res.f.Ft == FieldTypeObject {
+		res.elemSize = flatbuffers.SizeUOffsetT
+		res.node, err = p.parseOr(res.f.FieldScheme, FieldTypeUnspecified)
+	} else {
+		res.elemSize = flatbuffers.UOffsetT(fieldTypeSize(res.f.Ft))
+		if res.f.Ft == FieldTypeString {
+			res.elemSize = flatbuffers.SizeUOffsetT
+		}
+		res.node, err = p.parseOr(s, res.f.Ft)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, p.expectPunct(")")
+}
+
+// parsePath parses dot-separated names. Returns slots of nested objects and the last field
+func (p *filterParser) parsePath(s *Scheme) ([]flatbuffers.VOffsetT, *Field, error) {
+	steps := []flatbuffers.VOffsetT{}
+	for {
+		if p.err != nil {
+			return nil, nil, p.err
+		}
+		if p.tok.kind != filterTokName {
+			return nil, nil, p.error(ErrMalformedFilter, "field name expected")
+		}
+		f, ok := s.FieldsMap[p.tok.str]
+		if !ok {
+			return nil, nil, p.error(ErrUnknownField, "%s", p.tok.str)
+		}
+		p.next()
+		if !p.isPunct(".") {
+			return steps, f, nil
+		}
+		if f.Ft != FieldTypeObject || f.IsArray {
+			return nil, nil, p.error(ErrPathMismatch, "%s is not a nested object", f.QualifiedName())
+		}
+		steps = append(steps, flatbuffers.VOffsetT((f.Order+2)*2))
+		s = f.FieldScheme
+		p.next()
+	}
+}
+
+func (p *filterParser) parsePredicate(s *Scheme, itFt FieldType) (filterNode, error) {
+	res := &filterPredicate{}
+	isScalar := true
+	operand := p.tok.str
+	if p.isName("it") {
+		if itFt == FieldTypeUnspecified {
+			return nil, p.error(ErrPathMismatch, "`it` is available within a quantifier over an array of scalars or strings only")
+		}
+		res.ft = itFt
+		p.next()
+	} else {
+		steps, f, err := p.parsePath(s)
+		if err != nil {
+			return nil, err
+		}
+		res.steps, res.ft = steps, f.Ft
+		res.slot = flatbuffers.VOffsetT((f.Order + 2) * 2)
+		isScalar = !f.IsArray && f.Ft != FieldTypeObject
+		operand = f.QualifiedName()
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	op, ok := filterOps[p.tok.str]
+	if !ok || (p.tok.kind != filterTokOp && p.tok.kind != filterTokName) {
+		return nil, p.error(ErrMalformedFilter, "operator expected")
+	}
+	p.next()
+	if p.isName("null") {
+		switch op {
+		case filterOpEq:
+			res.op = filterOpIsNull
+		case filterOpNe:
+			res.op = filterOpIsNotNull
+		default:
+			return nil, p.error(ErrWrongValueType, "null could be compared by == or != only")
+		}
+		p.next()
+		return res, p.err
+	}
+	res.op = op
+	if !isScalar {
+		return nil, p.error(ErrPathMismatch, "%s could be compared with null only, use any() or all() for arrays", operand)
+	}
+	switch {
+	case op == filterOpStartsWith && res.ft != FieldTypeString:
+		return nil, p.error(ErrWrongValueType, "startsWith is applicable to strings only")
+	case op != filterOpEq && op != filterOpNe && op != filterOpIn && res.ft == FieldTypeBool:
+		return nil, p.error(ErrWrongValueType, "bool could be compared by == or != only")
+	}
+	if op != filterOpIn {
+		literal, err := p.parseLiteral(res.ft, operand)
+		if err != nil {
+			return nil, err
+		}
+		res.literals = append(res.literals, literal)
+		return res, nil
+	}
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+	for {
+		literal, err := p.parseLiteral(res.ft, operand)
+		if err != nil {
+			return nil, err
+		}
+		res.literals = append(res.literals, literal)
+		if !p.isPunct(",") {
+			break
+		}
+		p.next()
+	}
+	return res, p.expectPunct(")")
+}
+
+// parseLiteral parses the literal and checks if it fits the type
+func (p *filterParser) parseLiteral(ft FieldType, operand string) (filterLiteral, error) {
+	res := filterLiteral{}
+	if p.err != nil {
+		return res, p.err
+	}
+	if p.tok.kind != filterTokString && p.tok.kind != filterTokNumber && p.tok.kind != filterTokName {
+		return res, p.error(ErrMalformedFilter, "literal expected")
+	}
+	wrongType := func() (filterLiteral, error) {
+		return filterLiteral{}, p.error(ErrWrongValueType, "%s does not fit %s", p.tok.str, operand)
+	}
+	switch ft {
+	case FieldTypeString:
+		if p.tok.kind != filterTokString {
+			return wrongType()
+		}
+		str, err := strconv.Unquote(p.tok.str)
+		if err != nil {
+			return res, p.error(ErrMalformedFilter, "%s", p.tok.str)
+		}
+		res.s = []byte(str)
+	case FieldTypeBool:
+		if !p.isName("true") && !p.isName("false") {
+			return wrongType()
+		}
+		res.b = p.tok.str == "true"
+	case FieldTypeFloat32, FieldTypeFloat64:
+		if p.tok.kind != filterTokNumber {
+			return wrongType()
+		}
+		f, err := strconv.ParseFloat(p.tok.str, 64)
+		if err != nil {
+			return res, p.error(ErrMalformedFilter, "%s", p.tok.str)
+		}
+		res.f = f
+		if ft == FieldTypeFloat32 {
+			// stored float32 value is compared, e.g. float32(0.1) != 0.1
+			if math.Abs(f) > math.MaxFloat32 {
+				return wrongType()
+			}
+			res.f = float64(float32(f))
+		}
+	default:
+		if p.tok.kind != filterTokNumber {
+			return wrongType()
+		}
+		i, err := strconv.ParseInt(p.tok.str, 10, 64)
+		if err != nil {
+			if _, errFloat := strconv.ParseFloat(p.tok.str, 64); errFloat == nil {
+				return wrongType()
+			}
+			return res, p.error(ErrMalformedFilter, "%s", p.tok.str)
+		}
+		if !filterIntFits(i, ft) {
+			return wrongType()
+		}
+		res.i = i
+	}
+	p.next()
+	return res, p.err
+}
+
+func filterIntFits(i int64, ft FieldType) bool {
+	switch ft {
+	case FieldTypeInt16:
+		return i >= math.MinInt16 && i <= math.MaxInt16
+	case FieldTypeInt32:
+		return i >= math.MinInt32 && i <= math.MaxInt32
+	case FieldTypeByte:
+		return i >= 0 && i <= math.MaxUint8
+	}
+	return true
+}
@@ -0,0 +1,224 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(orderSchemeYaml + `
+flags..: bool
+total: float32
+code: byte
+discount: int16
+`)
+	require.NoError(err)
+	b := ReadBuffer(getOrderBytes(t, s), s)
+	b.Set("flags", []bool{false, true})
+	b.Set("total", float32(4.1))
+	b.Set("code", byte(200))
+	bytes, err := b.ToBytes()
+	require.NoError(err)
+	b.Release()
+	b = ReadBuffer(bytes, s)
+
+	for expr, expected := range map[string]bool{
+		`name == "order"`:                                  true,
+		`name != "order"`:                                  false,
+		`name > "ord" and name < "orders"`:                 true,
+		`name in ("a", "order")`:                           true,
+		`name in ("a", "b")`:                               false,
+		`name startsWith "ord"`:                            true,
+		`name startsWith "x"`:                              false,
+		`name startsWith ""`:                               true,
+		`article.id == 1`:                                  true,
+		`article.id >= 2`:                                  false,
+		`article.name == "cola" and not (article.id != 1)`: true,
+		`article.id == 2 or article.name == "cola"`:        true,
+		`not article.id == 1`:                              false,
+		`article != null and article.name != null`:         true,
+		`discount == null`:                                 true,
+		`discount != null`:                                 false,
+		`discount == 0`:                                    false,
+		`discount != 0`:                                    false,
+		`total == 4.1`:                                     true,
+		`total > 4.09999 and total < 4.10001`:              true,
+		`code == 200`:                                      true,
+		`code in (1, 2)`:                                   false,
+		`any(tags, it == "vip")`:                           true,
+		`all(tags, it == "vip")`:                           false,
+		`all(tags, it startsWith "" and name == "order")`:  true,
+		`any(lines, qty == 2 and price == 2.5)`:            true,
+		`any(lines, qty == 2 and price == 1.5)`:            false,
+		`all(lines, article.id >= 10)`:                     true,
+		`all(lines, article.name startsWith "art1")`:       false,
+		`any(lines, article.name in ("art20", "art30"))`:   true,
+		`any(flags, it == true)`:                           true,
+		`all(flags, it != true)`:                           false,
+		`lines != null and tags != null and flags != null`: true,
+	} {
+		f, err := s.CompileFilter(expr)
+		require.NoError(err, expr)
+		require.Equal(expr, f.String())
+		require.Equal(expected, f.Match(b), expr)
+		require.Equal(expected, f.MatchBytes(bytes), expr)
+	}
+
+	// unset values
+	empty := NewBuffer(s)
+	for expr, expected := range map[string]bool{
+		`name == null`:           true,
+		`name == "order"`:        false,
+		`name != "order"`:        false,
+		`not name == "order"`:    true,
+		`article.name == null`:   true,
+		`any(tags, it == "vip")`: false,
+		`all(tags, it == "vip")`: true,
+		`all(lines, qty > 100)`:  true,
+		`any(lines, qty > 100)`:  false,
+		`lines == null`:          true,
+		`all(lines, qty > 0) and any(tags, it == "vip") or name == null`: true,
+	} {
+		f, err := s.CompileFilter(expr)
+		require.NoError(err, expr)
+		require.Equal(expected, f.Match(empty), expr)
+	}
+	empty.Release()
+
+	// precedence: and binds tighter than or
+	f, err := s.CompileFilter(`name == "x" and article.id == 1 or name == "order"`)
+	require.NoError(err)
+	require.True(f.Match(b))
+	f, err = s.CompileFilter(`name == "x" and (article.id == 1 or name == "order")`)
+	require.NoError(err)
+	require.False(f.Match(b))
+
+	// no allocations
+	f, err = s.CompileFilter(`name startsWith "ord" and any(lines, article.name in ("art20")) and all(tags, it != "x") and total > 4`)
+	require.NoError(err)
+	require.True(f.Match(b))
+	require.Zero(testing.AllocsPerRun(100, func() { f.Match(b) }))
+
+	// nested objects and array elements
+	lineFilter, err := s.GetNestedScheme("lines").CompileFilter(`qty == 2 and article.name == "art20"`)
+	require.NoError(err)
+	lines := b.Get("lines").(*ObjectArray)
+	matched := []bool{}
+	for lines.Next() {
+		matched = append(matched, lineFilter.Match(lines.Buffer))
+	}
+	require.Equal([]bool{false, true}, matched)
+	articleFilter, err := s.GetNestedScheme("article").CompileFilter(`id == 1 and name == "cola"`)
+	require.NoError(err)
+	require.True(articleFilter.Match(b.Get("article").(*Buffer)))
+
+	// NaN: every comparison is false except !=
+	bNaN := NewBuffer(s)
+	bNaN.Set("total", float32(math.NaN()))
+	bNaN.Set("lines", []*Buffer{NewBuffer(s.GetNestedScheme("lines"))})
+	bNaN.GetMutableObjectArray("lines").At(0).Set("price", math.NaN())
+	nanBytes, err := bNaN.ToBytes()
+	require.NoError(err)
+	for expr, expected := range map[string]bool{
+		`total == 0`:                  false,
+		`total != 0`:                  true,
+		`total < 1`:                   false,
+		`total <= 1`:                  false,
+		`total > 1`:                   false,
+		`total >= 1`:                  false,
+		`total in (0, 1)`:             false,
+		`not total == 0`:              true,
+		`any(lines, price == 0)`:      false,
+		`any(lines, price != 2.5)`:    true,
+		`any(lines, price < 2.5)`:     false,
+		`any(lines, price >= 2.5)`:    false,
+		`all(lines, price in (1, 2))`: false,
+	} {
+		f, err := s.CompileFilter(expr)
+		require.NoError(err, expr)
+		require.Equal(expected, f.MatchBytes(nanBytes), expr)
+	}
+	bNaN.Release()
+
+	require.Panics(func() {
+		other := NewBuffer(NewScheme())
+		defer other.Release()
+		f.Match(other)
+	})
+	b.Release()
+	require.Zero(GetObjectsInUse())
+}
+
+func TestFilterErrors(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(orderSchemeYaml + `
+flags..: bool
+total: float32
+code: byte
+discount: int16
+`)
+	require.NoError(err)
+
+	for expr, expectedErr := range map[string]error{
+		``:                              ErrMalformedFilter,
+		`name`:                          ErrMalformedFilter,
+		`name ==`:                       ErrMalformedFilter,
+		`name == "order`:                ErrMalformedFilter,
+		`name == "order" and`:           ErrMalformedFilter,
+		`(name == "order"`:              ErrMalformedFilter,
+		`name == "order")`:              ErrMalformedFilter,
+		`name ~ "order"`:                ErrMalformedFilter,
+		`name in "order"`:               ErrMalformedFilter,
+		`name in ("a" "b")`:             ErrMalformedFilter,
+		`article..id == 1`:              ErrMalformedFilter,
+		`any(lines qty == 1)`:           ErrMalformedFilter,
+		`unknown == 1`:                  ErrUnknownField,
+		`article.unknown == 1`:          ErrUnknownField,
+		`any(lines, unknown == 1)`:      ErrUnknownField,
+		`name.id == 1`:                  ErrPathMismatch,
+		`lines.qty == 1`:                ErrPathMismatch,
+		`tags == "vip"`:                 ErrPathMismatch,
+		`article == 1`:                  ErrPathMismatch,
+		`any(name, it == "x")`:          ErrPathMismatch,
+		`any(article, id == 1)`:         ErrPathMismatch,
+		`it == 1`:                       ErrPathMismatch,
+		`any(lines, it == 1)`:           ErrPathMismatch,
+		`name == 1`:                     ErrWrongValueType,
+		`article.id == "1"`:             ErrWrongValueType,
+		`article.id == 1.5`:             ErrWrongValueType,
+		`article.id == true`:            ErrWrongValueType,
+		`any(lines, qty == 3000000000)`: ErrWrongValueType,
+		`discount == 40000`:             ErrWrongValueType,
+		`code == 256`:                   ErrWrongValueType,
+		`code == -1`:                    ErrWrongValueType,
+		`total == 1e39`:                 ErrWrongValueType,
+		`total == "1"`:                  ErrWrongValueType,
+		`any(flags, it > false)`:        ErrWrongValueType,
+		`any(flags, it == 1)`:           ErrWrongValueType,
+		`article.id startsWith "1"`:     ErrWrongValueType,
+		`name > null`:                   ErrWrongValueType,
+		`name in (1, 2)`:                ErrWrongValueType,
+		`all(flags, it)`:                ErrMalformedFilter,
+	} {
+		_, err := s.CompileFilter(expr)
+		require.ErrorIs(err, expectedErr, expr)
+		var fe *FilterError
+		require.ErrorAs(err, &fe, expr)
+		require.Equal(expr, fe.Expr)
+	}
+
+	_, err = s.CompileFilter(`name == "order" and unknown == 1`)
+	var fe *FilterError
+	require.ErrorAs(err, &fe)
+	require.Equal(20, fe.Pos)
+}
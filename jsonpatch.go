@@ -0,0 +1,473 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrMalformedPatchOp operation is unknown or its required member is missing
+	ErrMalformedPatchOp = errors.New("malformed operation")
+	// ErrTargetNotFound value at the path does not exist, i.e. unset
+	ErrTargetNotFound = errors.New("target location does not exist")
+	// ErrWrongValueType value does not match the field type
+	ErrWrongValueType = errors.New("value does not match the field type")
+	// ErrPatchTestFailed value at the path differs from the one provided to test operation
+	ErrPatchTestFailed = errors.New("test operation failed")
+)
+
+// JSONPatchError describes failed operation of JSON Patch. Wraps one of ErrMalformedPatchOp, ErrTargetNotFound, ErrWrongValueType, ErrPatchTestFailed,
+// ErrMalformedPath, ErrUnknownField, ErrPathMismatch, ErrIndexOutOfRange
+type JSONPatchError struct {
+	Index int // index of the failed operation
+	Op    string
+	Path  string
+	Err   error
+}
+
+func (e *JSONPatchError) Error() string {
+	return fmt.Sprintf("JSON Patch operation #%d %q at %q failed: %v", e.Index, e.Op, e.Path, e.Err)
+}
+
+func (e *JSONPatchError) Unwrap() error {
+	return e.Err
+}
+
+type jsonPatchOp struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// jsonPatchTarget is a location within JSON representation of a Buffer
+// owner == nil -> the root. elemToken != "" -> element of array field `f` of `owner`, member `f` of `owner` otherwise
+type jsonPatchTarget struct {
+	owner     map[string]interface{}
+	f         *Field
+	elemToken string
+}
+
+// ApplyJSONPatch applies JSON Patch (RFC 6902) operations add, remove, replace, move, copy and test. Modifications are considered on ToBytes()
+// Paths are JSON Pointers (RFC 6901) resolved against the Scheme: "/lines/2/qty", "/tags/-" to append
+// Unset or empty value is considered as absent, so remove, replace etc over an unset value -> error. `null` value unsets the field
+// Values are checked against the field types, numbers are converted by field types so int64 values are not rounded
+// Operations are applied atomically: any operation fails or the result could not be encoded -> the Buffer is not modified
+// Operations are applied to JSON representation of the Buffer (ToJSON()) and the difference is set, so any operation over an array element sets
+// the whole resulting array: pending array operations (RemoveAt() etc) of the field are applied and replaced by the array value
+// Failed operation -> *JSONPatchError
+func (b *Buffer) ApplyJSONPatch(ops []byte) error {
+	var patchOps []jsonPatchOp
+	if err := json.Unmarshal(ops, &patchOps); err != nil {
+		return fmt.Errorf("failed to parse JSON Patch: %w", err)
+	}
+	var oldDoc, doc map[string]interface{}
+	if err := unmarshalJSONNumbers(b.toJSON(nil), &oldDoc); err != nil {
+		return err
+	}
+	if err := unmarshalJSONNumbers(b.toJSON(nil), &doc); err != nil {
+		return err
+	}
+	for i, op := range patchOps {
+		var err error
+		if doc, err = b.applyJSONPatchOp(doc, op); err != nil {
+			path := ""
+			if op.Path != nil {
+				path = *op.Path
+			}
+			return &JSONPatchError{Index: i, Op: op.Op, Path: path, Err: err}
+		}
+	}
+	patch := mergePatchOf(oldDoc, doc)
+	if err := convertJSONNumbers(b.Scheme, patch); err != nil {
+		return err
+	}
+	// applyMergePatch() could fail after some fields are set -> try on a clone first, the result must be encodable too
+	clone, err := b.Clone()
+	if err != nil {
+		return err
+	}
+	defer clone.Release()
+	if err := clone.applyMergePatch(patch); err != nil {
+		return err
+	}
+	if _, err := clone.ToBytes(); err != nil {
+		return err
+	}
+	return b.applyMergePatch(patch)
+}
+
+func (b *Buffer) applyJSONPatchOp(doc map[string]interface{}, op jsonPatchOp) (map[string]interface{}, error) {
+	if op.Path == nil {
+		return nil, fmt.Errorf("%w: path is missing", ErrMalformedPatchOp)
+	}
+	target, err := b.resolveJSONPointer(doc, *op.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch op.Op {
+	case "add", "replace", "test":
+		if len(op.Value) == 0 {
+			return nil, fmt.Errorf("%w: value is missing", ErrMalformedPatchOp)
+		}
+		var value interface{}
+		if err := unmarshalJSONNumbers(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrMalformedPatchOp, err)
+		}
+		switch op.Op {
+		case "add":
+			return target.add(doc, value, b.Scheme)
+		case "replace":
+			if _, err := target.get(doc); err != nil {
+				return nil, err
+			}
+			if target.elemToken != "" {
+				if err := target.remove(); err != nil {
+					return nil, err
+				}
+			}
+			return target.add(doc, value, b.Scheme)
+		default:
+			actual, err := target.get(doc)
+			if err != nil {
+				return nil, err
+			}
+			if !equalJSONValues(actual, value) {
+				return nil, fmt.Errorf("%w: %v expected, %v actual", ErrPatchTestFailed, value, actual)
+			}
+			return doc, nil
+		}
+	case "remove":
+		if target.owner == nil {
+			return nil, fmt.Errorf("%w: the root could not be removed", ErrPathMismatch)
+		}
+		return doc, target.remove()
+	case "move", "copy":
+		if op.From == nil {
+			return nil, fmt.Errorf("%w: from is missing", ErrMalformedPatchOp)
+		}
+		if op.Op == "move" && strings.HasPrefix(*op.Path, *op.From+"/") {
+			return nil, fmt.Errorf("%w: location could not be moved into one of its children", ErrPathMismatch)
+		}
+		from, err := b.resolveJSONPointer(doc, *op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := from.get(doc)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if *op.From == *op.Path {
+				return doc, nil
+			}
+			if from.owner == nil {
+				return nil, fmt.Errorf("%w: the root could not be moved", ErrPathMismatch)
+			}
+			if err := from.remove(); err != nil {
+				return nil, err
+			}
+			// path could be changed by removal, e.g. moving an array element to the end
+			if target, err = b.resolveJSONPointer(doc, *op.Path); err != nil {
+				return nil, err
+			}
+		} else {
+			value = copyJSONValue(value)
+		}
+		return target.add(doc, value, b.Scheme)
+	}
+	return nil, fmt.Errorf("%w: unknown operation %q", ErrMalformedPatchOp, op.Op)
+}
+
+// resolveJSONPointer resolves JSON Pointer into the location within `doc`. Intermediate locations must exist
+func (b *Buffer) resolveJSONPointer(doc map[string]interface{}, pointer string) (jsonPatchTarget, error) {
+	if pointer == "" {
+		return jsonPatchTarget{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return jsonPatchTarget{}, fmt.Errorf("%w: must start with /", ErrMalformedPath)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(tokens[i], "~1", "/"), "~0", "~")
+	}
+	owner := doc
+	scheme := b.Scheme
+	for i := 0; ; {
+		f, ok := scheme.FieldsMap[tokens[i]]
+		if !ok {
+			return jsonPatchTarget{}, fmt.Errorf("%w: %s", ErrUnknownField, tokens[i])
+		}
+		if i == len(tokens)-1 {
+			return jsonPatchTarget{owner: owner, f: f}, nil
+		}
+		switch {
+		case f.IsArray:
+			if i+1 == len(tokens)-1 {
+				return jsonPatchTarget{owner: owner, f: f, elemToken: tokens[i+1]}, nil
+			}
+			if f.Ft != FieldTypeObject {
+				return jsonPatchTarget{}, fmt.Errorf("%w: %s is an array of scalars", ErrPathMismatch, f.QualifiedName())
+			}
+			elem, err := jsonPatchTarget{owner: owner, f: f, elemToken: tokens[i+1]}.get(doc)
+			if err != nil {
+				return jsonPatchTarget{}, err
+			}
+			owner = elem.(map[string]interface{})
+			i += 2
+		case f.Ft == FieldTypeObject:
+			nested, ok := owner[f.Name].(map[string]interface{})
+			if !ok {
+				return jsonPatchTarget{}, fmt.Errorf("%w: %s", ErrTargetNotFound, f.QualifiedName())
+			}
+			owner = nested
+			i++
+		default:
+			return jsonPatchTarget{}, fmt.Errorf("%w: %s is not an object", ErrPathMismatch, f.QualifiedName())
+		}
+		scheme = f.FieldScheme
+	}
+}
+
+// index parses array index. "-" is allowed on add only and means the end of the array
+func (t jsonPatchTarget) index(l int, isAdd bool) (int, error) {
+	if t.elemToken == "-" && isAdd {
+		return l, nil
+	}
+	if t.elemToken == "" || (len(t.elemToken) > 1 && t.elemToken[0] == '0') {
+		return 0, fmt.Errorf("%w: wrong array index %q", ErrMalformedPath, t.elemToken)
+	}
+	idx, err := strconv.Atoi(t.elemToken)
+	if err != nil || idx < 0 {
+		return 0, fmt.Errorf("%w: wrong array index %q", ErrMalformedPath, t.elemToken)
+	}
+	if idx > l || (idx == l && !isAdd) {
+		return 0, fmt.Errorf("%w: %d of %d for %s", ErrIndexOutOfRange, idx, l, t.f.QualifiedName())
+	}
+	return idx, nil
+}
+
+func (t jsonPatchTarget) get(doc map[string]interface{}) (interface{}, error) {
+	if t.owner == nil {
+		return doc, nil
+	}
+	value, ok := t.owner[t.f.Name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTargetNotFound, t.f.QualifiedName())
+	}
+	if t.elemToken == "" {
+		return value, nil
+	}
+	arr, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is a byte array", ErrPathMismatch, t.f.QualifiedName())
+	}
+	idx, err := t.index(len(arr), false)
+	if err != nil {
+		return nil, err
+	}
+	return arr[idx], nil
+}
+
+func (t jsonPatchTarget) add(doc map[string]interface{}, value interface{}, scheme *Scheme) (map[string]interface{}, error) {
+	if t.owner == nil {
+		valueMap, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: object is required for the root, %#v provided", ErrWrongValueType, value)
+		}
+		return valueMap, checkJSONObject(scheme, valueMap)
+	}
+	if t.elemToken == "" {
+		if err := checkJSONValue(t.f, false, value); err != nil {
+			return nil, err
+		}
+		if value == nil {
+			delete(t.owner, t.f.Name)
+		} else {
+			t.owner[t.f.Name] = value
+		}
+		return doc, nil
+	}
+	if err := checkJSONValue(t.f, true, value); err != nil {
+		return nil, err
+	}
+	var arr []interface{}
+	if existing, ok := t.owner[t.f.Name]; ok {
+		if arr, ok = existing.([]interface{}); !ok {
+			return nil, fmt.Errorf("%w: %s is a byte array", ErrPathMismatch, t.f.QualifiedName())
+		}
+	} else if t.f.Ft == FieldTypeByte {
+		return nil, fmt.Errorf("%w: %s is a byte array", ErrPathMismatch, t.f.QualifiedName())
+	}
+	idx, err := t.index(len(arr), true)
+	if err != nil {
+		return nil, err
+	}
+	arr = append(arr, nil)
+	copy(arr[idx+1:], arr[idx:])
+	arr[idx] = value
+	t.owner[t.f.Name] = arr
+	return doc, nil
+}
+
+func (t jsonPatchTarget) remove() error {
+	value, ok := t.owner[t.f.Name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrTargetNotFound, t.f.QualifiedName())
+	}
+	if t.elemToken == "" {
+		delete(t.owner, t.f.Name)
+		return nil
+	}
+	arr, ok := value.([]interface{})
+	if !ok {
+		return fmt.Errorf("%w: %s is a byte array", ErrPathMismatch, t.f.QualifiedName())
+	}
+	idx, err := t.index(len(arr), false)
+	if err != nil {
+		return err
+	}
+	arr = append(arr[:idx], arr[idx+1:]...)
+	if len(arr) == 0 {
+		delete(t.owner, t.f.Name) // empty array -> unset
+	} else {
+		t.owner[t.f.Name] = arr
+	}
+	return nil
+}
+
+// checkJSONValue checks if JSON value fits field `f` or its element if `isElem`
+func checkJSONValue(f *Field, isElem bool, value interface{}) error {
+	if value == nil {
+		if isElem {
+			return fmt.Errorf("%w: nil element of array field %s. Nils are not supported for array elements", ErrWrongValueType, f.QualifiedName())
+		}
+		return nil
+	}
+	ok := false
+	switch {
+	case f.IsArray && !isElem:
+		switch arr := value.(type) {
+		case []interface{}:
+			for _, elem := range arr {
+				if err := checkJSONValue(f, true, elem); err != nil {
+					return err
+				}
+			}
+			return nil
+		case string:
+			if f.Ft == FieldTypeByte {
+				_, err := base64.StdEncoding.DecodeString(arr)
+				ok = err == nil
+			}
+		}
+	case f.Ft == FieldTypeObject:
+		if valueMap, isMap := value.(map[string]interface{}); isMap {
+			return checkJSONObject(f.FieldScheme, valueMap)
+		}
+	case f.Ft == FieldTypeString:
+		_, ok = value.(string)
+	case f.Ft == FieldTypeBool:
+		_, ok = value.(bool)
+	default:
+		if number, isNumber := value.(json.Number); isNumber {
+			converted, err := jsonNumberToFieldType(f, number)
+			if err != nil {
+				return fmt.Errorf("%w: %w", ErrWrongValueType, err)
+			}
+			float64Value, isFloat64 := converted.(float64)
+			ok = !isFloat64 || IsFloat64ValueFitsIntoField(f, float64Value)
+		}
+	}
+	if !ok {
+		return fmt.Errorf("%w: %#v provided for field %s", ErrWrongValueType, value, f.QualifiedName())
+	}
+	return nil
+}
+
+func checkJSONObject(scheme *Scheme, value map[string]interface{}) error {
+	for name, fieldValue := range value {
+		f, ok := scheme.FieldsMap[name]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownField, name)
+		}
+		if err := checkJSONValue(f, false, fieldValue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// equalJSONValues returns true if values decoded by unmarshalJSONNumbers() are equal. Numbers are compared by value, e.g. 1 equals to 1.0
+func equalJSONValues(a, b interface{}) bool {
+	switch aTyped := a.(type) {
+	case json.Number:
+		bTyped, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		aInt, aErr := aTyped.Int64()
+		bInt, bErr := bTyped.Int64()
+		if aErr == nil && bErr == nil {
+			return aInt == bInt
+		}
+		aFloat, aErr := aTyped.Float64()
+		bFloat, bErr := bTyped.Float64()
+		return aErr == nil && bErr == nil && aFloat == bFloat
+	case map[string]interface{}:
+		bTyped, ok := b.(map[string]interface{})
+		if !ok || len(aTyped) != len(bTyped) {
+			return false
+		}
+		for k, v := range aTyped {
+			bValue, ok := bTyped[k]
+			if !ok || !equalJSONValues(v, bValue) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bTyped, ok := b.([]interface{})
+		if !ok || len(aTyped) != len(bTyped) {
+			return false
+		}
+		for i := range aTyped {
+			if !equalJSONValues(aTyped[i], bTyped[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// copyJSONValue returns deep copy of a value got by unmarshalJSONNumbers()
+func copyJSONValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(typed))
+		for k, v := range typed {
+			res[k] = copyJSONValue(v)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(typed))
+		for i, v := range typed {
+			res[i] = copyJSONValue(v)
+		}
+		return res
+	}
+	return value
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(orderSchemeYaml)
+	require.NoError(err)
+	orderBytes := getOrderBytes(t, s)
+
+	b := ReadBuffer(orderBytes, s)
+	require.NoError(b.ApplyJSONPatch([]byte(`[
+		{"op": "test", "path": "/name", "value": "order"},
+		{"op": "replace", "path": "/name", "value": "new order"},
+		{"op": "add", "path": "/tags/0", "value": "first"},
+		{"op": "add", "path": "/tags/-", "value": "last"},
+		{"op": "remove", "path": "/tags/1"},
+		{"op": "replace", "path": "/lines/1/qty", "value": 5},
+		{"op": "copy", "from": "/lines/0", "path": "/lines/-"},
+		{"op": "remove", "path": "/lines/2/article/name"},
+		{"op": "move", "from": "/article/name", "path": "/lines/0/article/name"},
+		{"op": "test", "path": "/lines/0/article", "value": {"id": 10, "name": "cola"}}
+	]`)))
+	require.NoError(b.CommitChanges())
+	require.JSONEq(`{
+		"name": "new order",
+		"tags": ["first", "delivery", "last"],
+		"article": {"id": 1},
+		"lines": [
+			{"qty": 1, "price": 1.5, "article": {"id": 10, "name": "cola"}},
+			{"qty": 5, "price": 2.5, "article": {"id": 20, "name": "art20"}},
+			{"qty": 1, "price": 1.5, "article": {"id": 10}}
+		]
+	}`, string(b.ToJSON()))
+
+	// null and removing the last element unset the field, "~1" is unescaped
+	require.NoError(b.ApplyJSONPatch([]byte(`[
+		{"op": "replace", "path": "/name", "value": null},
+		{"op": "remove", "path": "/article"},
+		{"op": "move", "from": "/lines/2", "path": "/lines/0"},
+		{"op": "remove", "path": "/lines/1"},
+		{"op": "remove", "path": "/lines/1"},
+		{"op": "remove", "path": "/lines/0"},
+		{"op": "add", "path": "/article", "value": {"name": "a~1b"}}
+	]`)))
+	require.NoError(b.CommitChanges())
+	require.JSONEq(`{"tags": ["first", "delivery", "last"], "article": {"name": "a~1b"}}`, string(b.ToJSON()))
+
+	// whole document is replaced
+	require.NoError(b.ApplyJSONPatch([]byte(`[{"op": "replace", "path": "", "value": {"name": "root"}}]`)))
+	require.NoError(b.CommitChanges())
+	require.JSONEq(`{"name": "root"}`, string(b.ToJSON()))
+	b.Release()
+
+	// failed operation -> the Buffer is not modified
+	b = ReadBuffer(orderBytes, s)
+	err = b.ApplyJSONPatch([]byte(`[
+		{"op": "replace", "path": "/name", "value": "other"},
+		{"op": "test", "path": "/tags/1", "value": "vip"}
+	]`))
+	require.ErrorIs(err, ErrPatchTestFailed)
+	var patchErr *JSONPatchError
+	require.True(errors.As(err, &patchErr))
+	require.Equal(1, patchErr.Index)
+	require.Equal("test", patchErr.Op)
+	require.Equal("/tags/1", patchErr.Path)
+	bOrig := ReadBuffer(orderBytes, s)
+	require.True(b.Equal(bOrig))
+
+	for ops, expectedErr := range map[string]error{
+		`[{"op": "unknown", "path": "/name"}]`:                                                     ErrMalformedPatchOp,
+		`[{"op": "add", "value": 1}]`:                                                              ErrMalformedPatchOp,
+		`[{"op": "add", "path": "/name"}]`:                                                         ErrMalformedPatchOp,
+		`[{"op": "move", "path": "/name"}]`:                                                        ErrMalformedPatchOp,
+		`[{"op": "add", "path": "name", "value": "str"}]`:                                          ErrMalformedPath,
+		`[{"op": "add", "path": "/tags/01", "value": "str"}]`:                                      ErrMalformedPath,
+		`[{"op": "remove", "path": "/tags/-"}]`:                                                    ErrMalformedPath,
+		`[{"op": "add", "path": "/unknown", "value": 1}]`:                                          ErrUnknownField,
+		`[{"op": "add", "path": "/article", "value": {"unknown": 1}}]`:                             ErrUnknownField,
+		`[{"op": "add", "path": "/name/id", "value": 1}]`:                                          ErrPathMismatch,
+		`[{"op": "add", "path": "/tags/0/id", "value": 1}]`:                                        ErrPathMismatch,
+		`[{"op": "remove", "path": ""}]`:                                                           ErrPathMismatch,
+		`[{"op": "move", "from": "/lines", "path": "/lines/0"}]`:                                   ErrPathMismatch,
+		`[{"op": "add", "path": "/tags/3", "value": "str"}]`:                                       ErrIndexOutOfRange,
+		`[{"op": "replace", "path": "/lines/2/qty", "value": 1}]`:                                  ErrIndexOutOfRange,
+		`[{"op": "add", "path": "/name", "value": 1}]`:                                             ErrWrongValueType,
+		`[{"op": "add", "path": "/tags/0", "value": null}]`:                                        ErrWrongValueType,
+		`[{"op": "add", "path": "/lines/0/qty", "value": 1.5}]`:                                    ErrWrongValueType,
+		`[{"op": "add", "path": "/article", "value": {"id": "str"}}]`:                              ErrWrongValueType,
+		`[{"op": "replace", "path": "", "value": []}]`:                                             ErrWrongValueType,
+		`[{"op": "copy", "from": "/lines/0/qty", "path": "/name"}]`:                                ErrWrongValueType,
+		`[{"op": "remove", "path": "/article/name"}, {"op": "remove", "path": "/article/name"}]`:   ErrTargetNotFound,
+		`[{"op": "remove", "path": "/article"}, {"op": "add", "path": "/article/id", "value": 1}]`: ErrTargetNotFound,
+	} {
+		err := b.ApplyJSONPatch([]byte(ops))
+		require.ErrorIs(err, expectedErr, ops)
+		require.True(errors.As(err, &patchErr), ops)
+	}
+	require.True(b.Equal(bOrig))
+	require.Error(b.ApplyJSONPatch([]byte(`{}`)))
+
+	// pending modifications could not be encoded -> error, the Buffer is not modified
+	b.Set("name", 42)
+	require.Error(b.ApplyJSONPatch([]byte(`[{"op": "replace", "path": "/tags/0", "value": "first"}]`)))
+	b.Set("name", "order")
+	require.True(b.Equal(bOrig))
+
+	// any element operation sets the whole resulting array, pending array operations are applied
+	b.RemoveAt("tags", 0)
+	require.NoError(b.ApplyJSONPatch([]byte(`[{"op": "add", "path": "/tags/-", "value": "last"}]`)))
+	require.NoError(b.CommitChanges())
+	require.Equal([]string{"delivery", "last"}, b.Get("tags"))
+
+	bOrig.Release()
+	b.Release()
+	require.Zero(GetObjectsInUse())
+}
+
+func TestApplyJSONPatchNumbers(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(`
+id: int64
+ids..: int64
+qty: int32
+price: float64
+`)
+	require.NoError(err)
+
+	// int64 values above 2^53 are not rounded, including unchanged elements of a changed array
+	b := NewBuffer(s)
+	b.Set("ids", []int64{9007199254740993, 9007199254740995})
+	b.Set("price", 0.1)
+	require.NoError(b.ApplyJSONPatch([]byte(`[
+		{"op": "add", "path": "/id", "value": 9007199254740993},
+		{"op": "add", "path": "/ids/-", "value": 9223372036854775807},
+		{"op": "test", "path": "/ids/0", "value": 9007199254740993},
+		{"op": "test", "path": "/price", "value": 0.1},
+		{"op": "add", "path": "/qty", "value": 1.0},
+		{"op": "test", "path": "/qty", "value": 1}
+	]`)))
+	bytes, err := b.ToBytes()
+	require.NoError(err)
+	b.Release()
+	b = ReadBuffer(bytes, s)
+	require.Equal(int64(9007199254740993), b.Get("id"))
+	require.Equal([]int64{9007199254740993, 9007199254740995, 9223372036854775807}, b.Get("ids"))
+	require.Equal(int32(1), b.Get("qty"))
+	require.Equal(0.1, b.Get("price"))
+
+	err = b.ApplyJSONPatch([]byte(`[{"op": "test", "path": "/ids/0", "value": 9007199254740992}]`))
+	require.ErrorIs(err, ErrPatchTestFailed)
+	err = b.ApplyJSONPatch([]byte(`[{"op": "add", "path": "/id", "value": 9223372036854775808}]`))
+	require.ErrorIs(err, ErrWrongValueType)
+	err = b.ApplyJSONPatch([]byte(`[{"op": "add", "path": "/qty", "value": 2147483648}]`))
+	require.ErrorIs(err, ErrWrongValueType)
+	b.Release()
+
+	require.Zero(GetObjectsInUse())
+}
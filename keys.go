@@ -0,0 +1,395 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Key is a concatenation of encoded values of key fields. Each value is a marker byte (unset or set) followed by the value bytes:
+//   - int16, int32, int64: big-endian with inverted sign bit
+//   - byte, bool: one byte
+//   - float32, float64: big-endian IEEE 754 bits, all bits are inverted for negative values, sign bit is inverted otherwise. -0 is encoded as 0
+//   - string: bytes where 0x00 is escaped as 0x00 0xFF, terminated by 0x00 0x01
+//
+// Bytes of a descending field are inverted. So lexicographic order of keys matches the order of values field by field. Unset value is less than any value
+
+const (
+	keyUnset      byte = 0x00
+	keySet        byte = 0x01
+	keyEscape     byte = 0xFF
+	keyTerminator byte = 0x01
+)
+
+// ErrMalformedKey is returned if a key could not be decoded
+var ErrMalformedKey = errors.New("malformed key")
+
+// KeyField is a field of a key. Desc -> values of the field are in descending order
+type KeyField struct {
+	Name string
+	Desc bool
+}
+
+// KeyEncoder encodes values of key fields of Buffers into keys whose lexicographic order matches the order of values
+// Goroutine-safe
+type KeyEncoder struct {
+	scheme *Scheme
+	fields []*Field
+	desc   []bool
+}
+
+// NewKeyEncoder creates KeyEncoder of the Scheme. Values are ordered by key fields in the provided order
+// Error wraps ErrUnknownField if there is no such field in the Scheme, ErrPathMismatch if the field is an array or a nested object
+func NewKeyEncoder(scheme *Scheme, keyFields ...KeyField) (*KeyEncoder, error) {
+	if len(keyFields) == 0 {
+		return nil, errors.New("key fields are not provided")
+	}
+	res := &KeyEncoder{
+		scheme: scheme,
+		fields: make([]*Field, len(keyFields)),
+		desc:   make([]bool, len(keyFields)),
+	}
+	for i, kf := range keyFields {
+		f, ok := scheme.FieldsMap[kf.Name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownField, kf.Name)
+		}
+		if f.IsArray || f.Ft == FieldTypeObject {
+			return nil, fmt.Errorf("%w: field %s is not a scalar or a string", ErrPathMismatch, f.QualifiedName())
+		}
+		res.fields[i] = f
+		res.desc[i] = kf.Desc
+	}
+	return res, nil
+}
+
+// AppendKey appends the key of the Buffer to dst and returns the extended slice. Pending modifications are considered
+// Returns error if pending modifications could not be encoded. Panics if the Buffer's Scheme is not the Scheme of the KeyEncoder
+func (ke *KeyEncoder) AppendKey(dst []byte, b *Buffer) ([]byte, error) {
+	if b.Scheme != ke.scheme {
+		panic("the Buffer's Scheme does not match the KeyEncoder's Scheme")
+	}
+	committed, release, err := b.committedView()
+	if err != nil {
+		return dst, err
+	}
+	defer release()
+	for i, f := range ke.fields {
+		start := len(dst)
+		uOffsetT := committed.getFieldUOffsetTByOrder(f.Order)
+		if uOffsetT == 0 {
+			dst = append(dst, keyUnset)
+		} else {
+			dst = append(dst, keySet)
+			switch f.Ft {
+			case FieldTypeInt16:
+				dst = appendKeyInt16(dst, committed.tab.GetInt16(uOffsetT))
+			case FieldTypeInt32:
+				dst = appendKeyInt32(dst, committed.tab.GetInt32(uOffsetT))
+			case FieldTypeInt64:
+				dst = appendKeyInt64(dst, committed.tab.GetInt64(uOffsetT))
+			case FieldTypeFloat32:
+				dst = appendKeyFloat32(dst, committed.tab.GetFloat32(uOffsetT))
+			case FieldTypeFloat64:
+				dst = appendKeyFloat64(dst, committed.tab.GetFloat64(uOffsetT))
+			case FieldTypeByte:
+				dst = append(dst, committed.tab.GetByte(uOffsetT))
+			case FieldTypeBool:
+				dst = appendKeyBool(dst, committed.tab.GetBool(uOffsetT))
+			case FieldTypeString:
+				dst = appendKeyString(dst, committed.tab.ByteVector(uOffsetT))
+			}
+		}
+		if ke.desc[i] {
+			invertBytes(dst[start:])
+		}
+	}
+	return dst, nil
+}
+
+// Key returns the key of the Buffer, see AppendKey()
+func (ke *KeyEncoder) Key(b *Buffer) ([]byte, error) {
+	return ke.AppendKey(nil, b)
+}
+
+// AppendValues appends the key of values of the first len(values) key fields to dst and returns the extended slice
+// Fewer values than key fields -> key prefix is made, e.g. to scan a range of keys, see KeyPrefixEnd(). nil value -> unset
+// Values are accepted as by Set(): typed value, int or float64 which fits the field type
+// Error wraps ErrWrongValueType if a value does not match the field type
+func (ke *KeyEncoder) AppendValues(dst []byte, values ...interface{}) ([]byte, error) {
+	if len(values) > len(ke.fields) {
+		return nil, fmt.Errorf("%d values provided but the key has %d fields", len(values), len(ke.fields))
+	}
+	for i, value := range values {
+		start := len(dst)
+		var ok bool
+		if dst, ok = appendKeyValue(dst, ke.fields[i], value); !ok {
+			return nil, fmt.Errorf("%w: %#v provided for field %s", ErrWrongValueType, value, ke.fields[i].QualifiedName())
+		}
+		if ke.desc[i] {
+			invertBytes(dst[start:])
+		}
+	}
+	return dst, nil
+}
+
+// Decode returns values of key fields. Unset value -> nil. Key prefix -> values of the encoded fields only
+// Values are typed as by Get(): int16, int32, int64, float32, float64, byte, bool, string
+// Error wraps ErrMalformedKey
+func (ke *KeyEncoder) Decode(key []byte) ([]interface{}, error) {
+	res := []interface{}{}
+	for i := 0; len(key) > 0; i++ {
+		if i == len(ke.fields) {
+			return nil, fmt.Errorf("%w: %d extra bytes", ErrMalformedKey, len(key))
+		}
+		f := ke.fields[i]
+		mask := byte(0)
+		if ke.desc[i] {
+			mask = 0xFF
+		}
+		marker := key[0] ^ mask
+		key = key[1:]
+		if marker == keyUnset {
+			res = append(res, nil)
+			continue
+		}
+		if marker != keySet {
+			return nil, fmt.Errorf("%w: wrong marker %d of field %s", ErrMalformedKey, marker, f.QualifiedName())
+		}
+		var value interface{}
+		var l int
+		if f.Ft == FieldTypeString {
+			value, l = decodeKeyString(key, mask)
+		} else {
+			l = fieldTypeSize(f.Ft)
+			if len(key) >= l {
+				var buf [8]byte
+				for j := 0; j < l; j++ {
+					buf[j] = key[j] ^ mask
+				}
+				value = decodeKeyScalar(buf[:l], f.Ft)
+			}
+		}
+		if value == nil || l > len(key) {
+			return nil, fmt.Errorf("%w: truncated value of field %s", ErrMalformedKey, f.QualifiedName())
+		}
+		res = append(res, value)
+		key = key[l:]
+	}
+	return res, nil
+}
+
+// KeyPrefixEnd returns the least key which is greater than all keys starting with the prefix, i.e. the exclusive end of the range scan by the prefix
+// nil -> there is no such key (the prefix is empty or consists of 0xFF bytes)
+func KeyPrefixEnd(prefix []byte) []byte {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] != 0xFF {
+			res := append([]byte{}, prefix[:i+1]...)
+			res[i]++
+			return res
+		}
+	}
+	return nil
+}
+
+func appendKeyValue(dst []byte, f *Field, value interface{}) ([]byte, bool) {
+	if value == nil {
+		return append(dst, keyUnset), true
+	}
+	if float64Src, ok := value.(float64); ok && f.Ft != FieldTypeFloat64 {
+		// e.g. a number from JSON
+		if !IsFloat64ValueFitsIntoField(f, float64Src) {
+			return dst, false
+		}
+		switch f.Ft {
+		case FieldTypeInt16:
+			value = int16(float64Src)
+		case FieldTypeInt32:
+			value = int32(float64Src)
+		case FieldTypeInt64:
+			value = int64(float64Src)
+		case FieldTypeFloat32:
+			value = float32(float64Src)
+		case FieldTypeByte:
+			value = byte(float64Src)
+		}
+	}
+	if intSrc, ok := value.(int); ok {
+		switch {
+		case f.Ft == FieldTypeInt16 && intSrc >= math.MinInt16 && intSrc <= math.MaxInt16:
+			value = int16(intSrc)
+		case f.Ft == FieldTypeInt32 && intSrc >= math.MinInt32 && intSrc <= math.MaxInt32:
+			value = int32(intSrc)
+		case f.Ft == FieldTypeInt64:
+			value = int64(intSrc)
+		case f.Ft == FieldTypeByte && intSrc >= 0 && intSrc <= math.MaxUint8:
+			value = byte(intSrc)
+		case f.Ft == FieldTypeFloat32:
+			value = float32(intSrc)
+		case f.Ft == FieldTypeFloat64:
+			value = float64(intSrc)
+		}
+	}
+	dst = append(dst, keySet)
+	switch val := value.(type) {
+	case int16:
+		if f.Ft == FieldTypeInt16 {
+			return appendKeyInt16(dst, val), true
+		}
+	case int32:
+		if f.Ft == FieldTypeInt32 {
+			return appendKeyInt32(dst, val), true
+		}
+	case int64:
+		if f.Ft == FieldTypeInt64 {
+			return appendKeyInt64(dst, val), true
+		}
+	case float32:
+		if f.Ft == FieldTypeFloat32 {
+			return appendKeyFloat32(dst, val), true
+		}
+	case float64:
+		if f.Ft == FieldTypeFloat64 {
+			return appendKeyFloat64(dst, val), true
+		}
+	case byte:
+		if f.Ft == FieldTypeByte {
+			return append(dst, val), true
+		}
+	case bool:
+		if f.Ft == FieldTypeBool {
+			return appendKeyBool(dst, val), true
+		}
+	case string:
+		if f.Ft == FieldTypeString {
+			return appendKeyString(dst, []byte(val)), true
+		}
+	}
+	return dst, false
+}
+
+func appendKeyInt16(dst []byte, value int16) []byte {
+	return binary.BigEndian.AppendUint16(dst, uint16(value)^(1<<15))
+}
+
+func appendKeyInt32(dst []byte, value int32) []byte {
+	return binary.BigEndian.AppendUint32(dst, uint32(value)^(1<<31))
+}
+
+func appendKeyInt64(dst []byte, value int64) []byte {
+	return binary.BigEndian.AppendUint64(dst, uint64(value)^(1<<63))
+}
+
+func appendKeyFloat32(dst []byte, value float32) []byte {
+	if value == 0 {
+		value = 0 // -0 -> 0
+	}
+	bits := math.Float32bits(value)
+	if bits&(1<<31) != 0 {
+		bits = ^bits
+	} else {
+		bits ^= 1 << 31
+	}
+	return binary.BigEndian.AppendUint32(dst, bits)
+}
+
+func appendKeyFloat64(dst []byte, value float64) []byte {
+	if value == 0 {
+		value = 0 // -0 -> 0
+	}
+	bits := math.Float64bits(value)
+	if bits&(1<<63) != 0 {
+		bits = ^bits
+	} else {
+		bits ^= 1 << 63
+	}
+	return binary.BigEndian.AppendUint64(dst, bits)
+}
+
+func appendKeyBool(dst []byte, value bool) []byte {
+	if value {
+		return append(dst, 1)
+	}
+	return append(dst, 0)
+}
+
+func appendKeyString(dst []byte, value []byte) []byte {
+	for _, c := range value {
+		dst = append(dst, c)
+		if c == 0 {
+			dst = append(dst, keyEscape)
+		}
+	}
+	return append(dst, 0, keyTerminator)
+}
+
+// decodeKeyScalar decodes value bytes which are not inverted
+func decodeKeyScalar(bytes []byte, ft FieldType) interface{} {
+	switch ft {
+	case FieldTypeInt16:
+		return int16(binary.BigEndian.Uint16(bytes) ^ (1 << 15))
+	case FieldTypeInt32:
+		return int32(binary.BigEndian.Uint32(bytes) ^ (1 << 31))
+	case FieldTypeInt64:
+		return int64(binary.BigEndian.Uint64(bytes) ^ (1 << 63))
+	case FieldTypeFloat32:
+		bits := binary.BigEndian.Uint32(bytes)
+		if bits&(1<<31) != 0 {
+			bits ^= 1 << 31
+		} else {
+			bits = ^bits
+		}
+		return math.Float32frombits(bits)
+	case FieldTypeFloat64:
+		bits := binary.BigEndian.Uint64(bytes)
+		if bits&(1<<63) != 0 {
+			bits ^= 1 << 63
+		} else {
+			bits = ^bits
+		}
+		return math.Float64frombits(bits)
+	case FieldTypeBool:
+		return bytes[0] != 0
+	default:
+		return bytes[0]
+	}
+}
+
+// decodeKeyString returns the string and count of read bytes. nil -> the string is malformed
+func decodeKeyString(key []byte, mask byte) (interface{}, int) {
+	res := []byte{}
+	for i := 0; i < len(key); i++ {
+		c := key[i] ^ mask
+		if c != 0 {
+			res = append(res, c)
+			continue
+		}
+		if i+1 == len(key) {
+			break
+		}
+		switch key[i+1] ^ mask {
+		case keyEscape:
+			res = append(res, 0)
+			i++
+		case keyTerminator:
+			return string(res), i + 2
+		default:
+			return nil, 0
+		}
+	}
+	return nil, 0
+}
+
+func invertBytes(bytes []byte) {
+	for i := range bytes {
+		bytes[i] = ^bytes[i]
+	}
+}
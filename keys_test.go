@@ -0,0 +1,211 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var keysSchemeYaml = `
+tableNo: int32
+openedAt: int64
+qty: int16
+code: byte
+weight: float32
+price: float64
+paid: bool
+name: string
+lines..:
+  qty: int32
+`
+
+func TestKeyEncoderOrder(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(keysSchemeYaml)
+	require.NoError(err)
+	rnd := rand.New(rand.NewSource(1))
+
+	values := map[string][]interface{}{
+		"tableNo":  {int32(math.MinInt32), int32(-1), int32(0), int32(1), int32(math.MaxInt32)},
+		"openedAt": {int64(math.MinInt64), int64(-256), int64(0), int64(255), int64(math.MaxInt64)},
+		"qty":      {int16(math.MinInt16), int16(-1), int16(0), int16(1), int16(math.MaxInt16)},
+		"code":     {byte(0), byte(1), byte(255)},
+		"weight":   {float32(math.Inf(-1)), float32(-1.5), float32(-math.SmallestNonzeroFloat32), float32(0), float32(math.SmallestNonzeroFloat32), float32(2.5), float32(math.MaxFloat32)},
+		"price":    {math.Inf(-1), -1e300, -0.5, math.Copysign(0, -1), 0.5, 1e300, math.Inf(1)},
+		"paid":     {false, true},
+		"name":     {"a", "a\x00", "a\x00\x00", "a\x01", "ab", "b", "\xff"},
+	}
+	buffers := []*Buffer{}
+	for i := 0; i < 300; i++ {
+		b := NewBuffer(s)
+		for name, vals := range values {
+			if rnd.Intn(5) > 0 {
+				b.Set(name, vals[rnd.Intn(len(vals))])
+			}
+		}
+		bytes, err := b.ToBytes()
+		require.NoError(err)
+		b.Release()
+		buffers = append(buffers, ReadBuffer(bytes, s))
+	}
+
+	for _, keyFields := range [][]KeyField{
+		{{Name: "tableNo"}, {Name: "openedAt"}},
+		{{Name: "tableNo", Desc: true}, {Name: "openedAt"}},
+		{{Name: "name"}, {Name: "qty", Desc: true}},
+		{{Name: "name", Desc: true}, {Name: "code"}},
+		{{Name: "weight"}, {Name: "paid", Desc: true}, {Name: "price"}},
+		{{Name: "price", Desc: true}, {Name: "name"}, {Name: "weight", Desc: true}},
+		{{Name: "paid"}, {Name: "code", Desc: true}, {Name: "name"}, {Name: "tableNo"}},
+	} {
+		ke, err := NewKeyEncoder(s, keyFields...)
+		require.NoError(err)
+		keys := make([][]byte, len(buffers))
+		for i, b := range buffers {
+			keys[i], err = ke.Key(b)
+			require.NoError(err)
+			decoded, err := ke.Decode(keys[i])
+			require.NoError(err)
+			require.Len(decoded, len(keyFields))
+			for j, kf := range keyFields {
+				require.Equal(b.Get(kf.Name), decoded[j])
+			}
+		}
+		for i := range buffers {
+			for j := range buffers {
+				expected := 0
+				for _, kf := range keyFields {
+					if expected = compare(t, buffers[i], buffers[j], kf.Name); expected != 0 {
+						if kf.Desc {
+							expected = -expected
+						}
+						break
+					}
+				}
+				if actual := bytes.Compare(keys[i], keys[j]); actual != expected {
+					require.Failf("wrong order", "%v: %s vs %s: expected %d, got %d", keyFields, buffers[i].ToJSON(), buffers[j].ToJSON(), expected, actual)
+				}
+			}
+		}
+	}
+	for _, b := range buffers {
+		b.Release()
+	}
+	require.Zero(GetObjectsInUse())
+}
+
+func TestKeyEncoder(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(keysSchemeYaml)
+	require.NoError(err)
+
+	ke, err := NewKeyEncoder(s, KeyField{Name: "tableNo"}, KeyField{Name: "openedAt", Desc: true}, KeyField{Name: "name"})
+	require.NoError(err)
+
+	// key of stored bytes equals to key of values
+	b := NewBuffer(s)
+	b.Set("tableNo", int32(5))
+	b.Set("openedAt", int64(1000))
+	bytesModified, err := ke.Key(b)
+	require.NoError(err)
+	bs, err := b.ToBytes()
+	require.NoError(err)
+	b.Release()
+	b = ReadBuffer(bs, s)
+	key, err := ke.AppendKey([]byte("prefix/"), b)
+	require.NoError(err)
+	require.Equal(append([]byte("prefix/"), bytesModified...), key)
+
+	// pending modifications could not be encoded -> error
+	b.Set("tableNo", "str")
+	_, err = ke.Key(b)
+	require.Error(err)
+	b.Release()
+
+	key, err = ke.AppendValues(nil, 5, float64(1000), nil)
+	require.NoError(err)
+	require.Equal(bytesModified, key)
+	values, err := ke.Decode(key)
+	require.NoError(err)
+	require.Equal([]interface{}{int32(5), int64(1000), nil}, values)
+
+	// prefix range scan
+	prefix, err := ke.AppendValues([]byte("orders/"), int32(5))
+	require.NoError(err)
+	end := KeyPrefixEnd(prefix)
+	require.True(bytes.HasPrefix(prefix, []byte("orders/")))
+	inRange := append([]byte("orders/"), key...)
+	require.True(bytes.Compare(prefix, inRange) < 0)
+	require.True(bytes.Compare(inRange, end) < 0)
+	next, err := ke.AppendValues([]byte("orders/"), int32(6), int64(math.MaxInt64))
+	require.NoError(err)
+	require.True(bytes.Compare(end, next) <= 0)
+	values, err = ke.Decode(prefix[len("orders/"):])
+	require.NoError(err)
+	require.Equal([]interface{}{int32(5)}, values)
+	require.Equal([]byte{1, 3}, KeyPrefixEnd([]byte{1, 2, 0xff}))
+	require.Nil(KeyPrefixEnd([]byte{0xff, 0xff}))
+	require.Nil(KeyPrefixEnd(nil))
+
+	// wrong values
+	for _, vals := range [][]interface{}{
+		{"5"},
+		{int64(5)},
+		{1.5},
+		{math.MaxInt32 + 1},
+		{5, int32(1000)},
+		{5, int64(1), 1},
+		{5, int64(1), "a", 1},
+	} {
+		_, err := ke.AppendValues(nil, vals...)
+		require.Error(err, "%v", vals)
+	}
+	_, err = ke.AppendValues(nil, 5, int64(1), "a", 1)
+	require.NotErrorIs(err, ErrWrongValueType)
+	_, err = ke.AppendValues(nil, "5")
+	require.ErrorIs(err, ErrWrongValueType)
+
+	// malformed keys
+	key, err = ke.AppendValues(nil, 5, int64(1000), "a\x00b")
+	require.NoError(err)
+	values, err = ke.Decode(key)
+	require.NoError(err)
+	require.Equal([]interface{}{int32(5), int64(1000), "a\x00b"}, values)
+	for i := 1; i < len(key); i++ {
+		_, err = ke.Decode(key[:i])
+		if i == 5 || i == 14 { // prefixes of whole fields
+			require.NoError(err, i)
+		} else {
+			require.ErrorIs(err, ErrMalformedKey, i)
+		}
+	}
+	_, err = ke.Decode(append(copyBytes(key), 0))
+	require.ErrorIs(err, ErrMalformedKey)
+	_, err = ke.Decode([]byte{2})
+	require.ErrorIs(err, ErrMalformedKey)
+
+	// wrong key fields
+	_, err = NewKeyEncoder(s)
+	require.Error(err)
+	_, err = NewKeyEncoder(s, KeyField{Name: "unknown"})
+	require.ErrorIs(err, ErrUnknownField)
+	_, err = NewKeyEncoder(s, KeyField{Name: "lines"})
+	require.ErrorIs(err, ErrPathMismatch)
+
+	require.Panics(func() {
+		other := NewBuffer(NewScheme())
+		defer other.Release()
+		_, _ = ke.Key(other)
+	})
+	require.Zero(GetObjectsInUse())
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// ErrMandatoryFieldsNotSet is returned if stored bytes have no values for mandatory fields
+var ErrMandatoryFieldsNotSet = errors.New("mandatory fields are not set")
+
+// MandatoryFieldsError lists paths to all unset mandatory fields, e.g. `lines[3].article.id`
+type MandatoryFieldsError struct {
+	Paths []string
+}
+
+func (e *MandatoryFieldsError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrMandatoryFieldsNotSet, strings.Join(e.Paths, ", "))
+}
+
+func (e *MandatoryFieldsError) Unwrap() error {
+	return ErrMandatoryFieldsNotSet
+}
+
+// CheckMandatory checks stored bytes have values for all mandatory fields including ones of nested objects and array elements
+// Useful for bytes written by other writers: mandatory fields are checked on ToBytes() only. Modifications are not considered
+// Returns *MandatoryFieldsError if there are unset mandatory fields
+func (b *Buffer) CheckMandatory() error {
+	paths := checkMandatory(b.Scheme, b.tab, "", nil)
+	if len(paths) > 0 {
+		return &MandatoryFieldsError{Paths: paths}
+	}
+	return nil
+}
+
+func checkMandatory(s *Scheme, tab flatbuffers.Table, prefix string, paths []string) []string {
+	for _, f := range s.Fields {
+		uOffsetT := flatbuffers.UOffsetT(0)
+		if len(tab.Bytes) > 0 {
+			uOffsetT = flatbuffers.UOffsetT(tab.Offset(flatbuffers.VOffsetT((f.Order + 2) * 2)))
+		}
+		if uOffsetT == 0 {
+			if f.IsMandatory {
+				paths = append(paths, prefix+f.Name)
+			}
+			continue
+		}
+		if f.Ft != FieldTypeObject {
+			continue
+		}
+		if !f.IsArray {
+			paths = checkMandatory(f.FieldScheme, flatbuffers.Table{Bytes: tab.Bytes, Pos: tab.Indirect(tab.Pos + uOffsetT)}, prefix+f.Name+".", paths)
+			continue
+		}
+		// elements are stored in reverse order
+		vector := tab.Vector(uOffsetT)
+		l := tab.VectorLen(uOffsetT)
+		for i := 0; i < l; i++ {
+			elem := flatbuffers.Table{Bytes: tab.Bytes, Pos: tab.Indirect(vector + flatbuffers.UOffsetT(l-1-i)*flatbuffers.SizeUOffsetT)}
+			paths = checkMandatory(f.FieldScheme, elem, prefix+f.Name+"["+strconv.Itoa(i)+"].", paths)
+		}
+	}
+	return paths
+}
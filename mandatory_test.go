@@ -0,0 +1,98 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckMandatory(t *testing.T) {
+	require := require.New(t)
+	sMandatory, err := YamlToScheme(`
+Name: string
+qty: int32
+Article:
+  Id: int64
+  name: string
+lines..:
+  Qty: int32
+  article:
+    Id: int64
+    name: string
+`)
+	require.NoError(err)
+	// the same layout but without mandatory fields, e.g. an older version
+	s, err := YamlToScheme(`
+name: string
+qty: int32
+article:
+  id: int64
+  name: string
+lines..:
+  qty: int32
+  article:
+    id: int64
+    name: string
+`)
+	require.NoError(err)
+
+	b := NewBuffer(s)
+	bytes, _, err := b.ApplyJSONAndToBytes([]byte(`{
+		"qty": 1,
+		"article": {"name": "art"},
+		"lines": [
+			{"qty": 1, "article": {"id": 1}},
+			{"article": {"id": 2}},
+			{"qty": 3, "article": {"name": "art3"}}
+		]
+	}`))
+	require.NoError(err)
+	bytes = copyBytes(bytes)
+	b.Release()
+
+	b = ReadBuffer(bytes, sMandatory)
+	err = b.CheckMandatory()
+	require.ErrorIs(err, ErrMandatoryFieldsNotSet)
+	var mandatoryErr *MandatoryFieldsError
+	require.True(errors.As(err, &mandatoryErr))
+	require.Equal([]string{"name", "article.id", "lines[1].qty", "lines[2].article.id"}, mandatoryErr.Paths)
+	b.Release()
+
+	b, err = ReadBufferWithOptions(bytes, sMandatory, WithMandatoryCheck())
+	require.ErrorIs(err, ErrMandatoryFieldsNotSet)
+	require.Nil(b)
+
+	// no checks -> no errors
+	b, err = ReadBufferWithOptions(bytes, sMandatory)
+	require.NoError(err)
+	require.Equal(int32(1), b.Get("qty"))
+	b.Release()
+
+	// empty bytes
+	b = ReadBuffer(nil, sMandatory)
+	err = b.CheckMandatory()
+	require.True(errors.As(err, &mandatoryErr))
+	require.Equal([]string{"name", "article"}, mandatoryErr.Paths)
+	b.Release()
+
+	// all mandatory fields are set
+	b = NewBuffer(sMandatory)
+	bytes, _, err = b.ApplyJSONAndToBytes([]byte(`{"name": "str", "article": {"id": 1}, "lines": [{"qty": 1}]}`))
+	require.NoError(err)
+	bytes = copyBytes(bytes)
+	b.Release()
+	b, err = ReadBufferWithOptions(bytes, sMandatory, WithVerification(DefaultVerifyOptions), WithMandatoryCheck())
+	require.NoError(err)
+	require.NoError(b.CheckMandatory())
+	b.Release()
+
+	require.Zero(GetObjectsInUse())
+}
@@ -0,0 +1,296 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+)
+
+// ApplyMergePatch applies JSON Merge Patch (RFC 7396) to the Buffer. Modifications are considered on ToBytes()
+// `null` unsets the field, nested objects are merged recursively into existing ones (stored or pending), arrays and the rest values replace existing ones
+// Patch is not an object, unknown field or object is provided for a non-object field or vice versa -> error
+// Value rules are the same as for ApplyMap(): value type and field type are incompatible -> error on ToBytes()
+// Numbers are converted by field types so int64 values are not rounded. Number is out of range of the field type -> error
+// Note: the Buffer could be partially modified on error
+func (b *Buffer) ApplyMergePatch(patch []byte) error {
+	var data interface{}
+	if err := unmarshalJSONNumbers(patch, &data); err != nil {
+		return fmt.Errorf("failed to parse merge patch: %w", err)
+	}
+	dataMap, ok := data.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("merge patch must be an object, %s provided", string(patch))
+	}
+	if err := convertJSONNumbers(b.Scheme, dataMap); err != nil {
+		return err
+	}
+	return b.applyMergePatch(dataMap)
+}
+
+// unmarshalJSONNumbers is json.Unmarshal() which decodes numbers as json.Number
+func unmarshalJSONNumbers(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("invalid data after top-level value")
+	}
+	return nil
+}
+
+// convertJSONNumbers replaces json.Number values of the map by values of the Scheme field types
+// Values of unknown fields, of non-numeric fields and numbers with fraction or exponent for integer fields are converted to float64
+// so they are processed by ApplyMap() rules
+func convertJSONNumbers(s *Scheme, data map[string]interface{}) error {
+	for fn, fv := range data {
+		var f *Field
+		if s != nil {
+			f = s.FieldsMap[fn]
+		}
+		converted, err := convertJSONValue(f, fv)
+		if err != nil {
+			return err
+		}
+		data[fn] = converted
+	}
+	return nil
+}
+
+// convertJSONValue converts json.Number values of the value of the field, see convertJSONNumbers(). nil field -> unknown field
+func convertJSONValue(f *Field, value interface{}) (interface{}, error) {
+	switch typed := value.(type) {
+	case json.Number:
+		if f == nil || f.IsArray {
+			return jsonNumberToFloat64(typed)
+		}
+		return jsonNumberToFieldType(f, typed)
+	case map[string]interface{}:
+		var s *Scheme
+		if f != nil {
+			s = f.FieldScheme
+		}
+		return typed, convertJSONNumbers(s, typed)
+	case []interface{}:
+		if f != nil && f.IsArray && f.Ft != FieldTypeObject && f.Ft != FieldTypeByte {
+			return jsonNumbersToArray(f, typed)
+		}
+		elemField := f
+		if f != nil && !f.IsArray {
+			elemField = nil
+		}
+		for i, elem := range typed {
+			converted, err := convertJSONValue(elemField, elem)
+			if err != nil {
+				return nil, err
+			}
+			typed[i] = converted
+		}
+	}
+	return value, nil
+}
+
+// jsonNumbersToArray converts array of json.Number to a typed slice of the field type
+// Elements are not numbers or could not be converted exactly -> []interface{} of float64 numbers
+func jsonNumbersToArray(f *Field, arr []interface{}) (interface{}, error) {
+	var res reflect.Value
+	switch f.Ft {
+	case FieldTypeInt16:
+		res = reflect.ValueOf(make([]int16, len(arr)))
+	case FieldTypeInt32:
+		res = reflect.ValueOf(make([]int32, len(arr)))
+	case FieldTypeInt64:
+		res = reflect.ValueOf(make([]int64, len(arr)))
+	case FieldTypeFloat32:
+		res = reflect.ValueOf(make([]float32, len(arr)))
+	case FieldTypeFloat64:
+		res = reflect.ValueOf(make([]float64, len(arr)))
+	}
+	isTyped := res.IsValid()
+	for i, elem := range arr {
+		number, ok := elem.(json.Number)
+		if !ok {
+			isTyped = false
+			continue
+		}
+		converted, err := jsonNumberToFieldType(f, number)
+		if err != nil {
+			return nil, err
+		}
+		if isTyped {
+			if v := reflect.ValueOf(converted); v.Type() == res.Type().Elem() {
+				res.Index(i).Set(v)
+			} else {
+				isTyped = false
+			}
+		}
+		if arr[i], err = jsonNumberToFloat64(number); err != nil {
+			return nil, err
+		}
+	}
+	if isTyped {
+		return res.Interface(), nil
+	}
+	return arr, nil
+}
+
+// jsonNumberToFieldType converts the number to the Go type of the scalar field
+// Number is out of range of the field type -> error. Non-numeric field or non-integer number for integer field -> float64
+func jsonNumberToFieldType(f *Field, number json.Number) (interface{}, error) {
+	var res interface{}
+	var err error
+	switch f.Ft {
+	case FieldTypeInt16:
+		var v int64
+		v, err = strconv.ParseInt(string(number), 10, 16)
+		res = int16(v)
+	case FieldTypeInt32:
+		var v int64
+		v, err = strconv.ParseInt(string(number), 10, 32)
+		res = int32(v)
+	case FieldTypeInt64:
+		res, err = strconv.ParseInt(string(number), 10, 64)
+	case FieldTypeByte:
+		var v uint64
+		v, err = strconv.ParseUint(string(number), 10, 8)
+		res = byte(v)
+	case FieldTypeFloat32:
+		var v float64
+		v, err = strconv.ParseFloat(string(number), 32)
+		res = float32(v)
+	case FieldTypeFloat64:
+		res, err = strconv.ParseFloat(string(number), 64)
+	default:
+		return jsonNumberToFloat64(number)
+	}
+	if errors.Is(err, strconv.ErrRange) {
+		return nil, fmt.Errorf("value %s is out of range of field %s", number, f.QualifiedName())
+	}
+	if err != nil {
+		// fraction or exponent for an integer field
+		return jsonNumberToFloat64(number)
+	}
+	return res, nil
+}
+
+func jsonNumberToFloat64(number json.Number) (float64, error) {
+	res, err := number.Float64()
+	if err != nil {
+		return 0, fmt.Errorf("number %s could not be represented as float64: %w", number, err)
+	}
+	return res, nil
+}
+
+func (b *Buffer) applyMergePatch(patch map[string]interface{}) error {
+	for fn, fv := range patch {
+		f, ok := b.Scheme.FieldsMap[fn]
+		if !ok {
+			return fmt.Errorf("field %s does not exist in the scheme", fn)
+		}
+		if fv == nil {
+			b.set(f, nil)
+			continue
+		}
+		if f.Ft != FieldTypeObject {
+			b.set(f, fv)
+			continue
+		}
+		if f.IsArray {
+			buffers, err := b.mapsToBuffersSlice(f, fv)
+			if err != nil {
+				return err
+			}
+			b.set(f, buffers)
+			continue
+		}
+		patchNested, ok := fv.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("value of field %s must be an object, %#v provided", f.QualifiedName(), fv)
+		}
+		if err := b.getNestedForModification(f).applyMergePatch(patchNested); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getNestedForModification returns nested object considering pending modifications. Unset -> an empty one is created
+func (b *Buffer) getNestedForModification(f *Field) *Buffer {
+	b.prepareFieldsToBytes()
+	m := &b.fieldsToBytes[f.Order]
+	if m.hasValue {
+		if nested, ok := m.value.(*Buffer); ok && nested != nil {
+			return nested
+		}
+	} else if nested, ok := b.getByField(f).(*Buffer); ok {
+		return nested // cached by getByField()
+	}
+	nested := NewBuffer(f.FieldScheme)
+	b.set(f, nested)
+	return nested
+}
+
+// MergePatch returns minimal JSON Merge Patch (RFC 7396) which turns `oldBuf` into `newBuf`. Pending modifications are considered
+// Unset or empty value -> `null`, nested objects are compared recursively, changed arrays are provided entirely
+// Fields are matched by name. `oldBuf` and `newBuf` are equal -> `{}`
+// Error is returned if pending modifications could not be encoded
+func MergePatch(oldBuf, newBuf *Buffer) ([]byte, error) {
+	oldView, oldRelease, err := oldBuf.committedView()
+	if err != nil {
+		return nil, err
+	}
+	defer oldRelease()
+	newView, newRelease, err := newBuf.committedView()
+	if err != nil {
+		return nil, err
+	}
+	defer newRelease()
+	oldMap := map[string]interface{}{}
+	if oldView != nil {
+		oldMap = oldView.toJSONMap(nil)
+	}
+	newMap := map[string]interface{}{}
+	if newView != nil {
+		newMap = newView.toJSONMap(nil)
+	}
+	return json.Marshal(mergePatchOf(oldMap, newMap))
+}
+
+func mergePatchOf(oldMap, newMap map[string]interface{}) map[string]interface{} {
+	res := map[string]interface{}{}
+	for name := range oldMap {
+		if _, ok := newMap[name]; !ok {
+			res[name] = nil
+		}
+	}
+	for name, newValue := range newMap {
+		oldValue, ok := oldMap[name]
+		if ok {
+			newNested, newIsObject := newValue.(map[string]interface{})
+			oldNested, oldIsObject := oldValue.(map[string]interface{})
+			if newIsObject && oldIsObject {
+				if nestedPatch := mergePatchOf(oldNested, newNested); len(nestedPatch) > 0 {
+					res[name] = nestedPatch
+				}
+				continue
+			}
+			if reflect.DeepEqual(oldValue, newValue) {
+				continue
+			}
+		}
+		res[name] = newValue
+	}
+	return res
+}
@@ -0,0 +1,163 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(orderSchemeYaml)
+	require.NoError(err)
+	orderBytes := getOrderBytes(t, s)
+
+	b := ReadBuffer(orderBytes, s)
+	require.NoError(b.ApplyMergePatch([]byte(`{
+		"name": null,
+		"tags": ["new"],
+		"article": {"name": "fanta"},
+		"lines": [{"qty": 3, "article": {"id": 30, "name": null}}]
+	}`)))
+	require.NoError(b.CommitChanges())
+	require.JSONEq(`{
+		"tags": ["new"],
+		"article": {"id": 1, "name": "fanta"},
+		"lines": [{"qty": 3, "article": {"id": 30}}]
+	}`, string(b.ToJSON()))
+
+	// pending nested object is merged
+	b.Set("article", NewBuffer(s.GetNestedScheme("article")))
+	require.NoError(b.ApplyMergePatch([]byte(`{"article": {"id": 2}}`)))
+	require.NoError(b.ApplyMergePatch([]byte(`{"article": {"name": "sprite"}}`)))
+	require.JSONEq(`{"id": 2, "name": "sprite"}`, string(b.Get("article").(*Buffer).ToJSON()))
+
+	// unset nested object is created
+	b.Release()
+	b = NewBuffer(s)
+	require.NoError(b.ApplyMergePatch([]byte(`{"article": {"id": 3}, "tags": []}`)))
+	require.JSONEq(`{"article": {"id": 3}}`, string(b.ToJSON()))
+
+	// errors
+	require.Error(b.ApplyMergePatch([]byte(`[]`)))
+	require.Error(b.ApplyMergePatch([]byte(`{`)))
+	require.Error(b.ApplyMergePatch([]byte(`{"unknown": 1}`)))
+	require.Error(b.ApplyMergePatch([]byte(`{"article": 1}`)))
+	require.Error(b.ApplyMergePatch([]byte(`{"lines": {}}`)))
+	require.Error(b.ApplyMergePatch([]byte(`{"lines": [1]}`)))
+	require.Error(b.ApplyMergePatch([]byte(`{"article": {"unknown": 1}}`)))
+	require.NoError(b.ApplyMergePatch([]byte(`{"name": 1}`)))
+	_, err = b.ToBytes()
+	require.Error(err)
+
+	b.Release()
+	require.Zero(GetObjectsInUse())
+}
+
+func TestApplyMergePatchNumbers(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(`
+id: int64
+qty: int32
+small: int16
+b: byte
+price: float32
+ids..: int64
+lines..:
+  id: int64
+`)
+	require.NoError(err)
+
+	// int64 values above 2^53 are not rounded
+	b := NewBuffer(s)
+	require.NoError(b.ApplyMergePatch([]byte(`{
+		"id": 9007199254740993,
+		"qty": 1e3,
+		"price": 0.1,
+		"b": 255,
+		"ids": [9007199254740993, -9223372036854775808],
+		"lines": [{"id": 9223372036854775807}]
+	}`)))
+	bytes, err := b.ToBytes()
+	require.NoError(err)
+	b.Release()
+	b = ReadBuffer(bytes, s)
+	require.Equal(int64(9007199254740993), b.Get("id"))
+	require.Equal(int32(1000), b.Get("qty"))
+	require.Equal(float32(0.1), b.Get("price"))
+	require.Equal(byte(255), b.Get("b"))
+	require.Equal([]int64{9007199254740993, -9223372036854775808}, b.Get("ids"))
+	lines := b.Get("lines").(*ObjectArray)
+	require.True(lines.Next())
+	require.Equal(int64(9223372036854775807), lines.Buffer.Get("id"))
+	b.Release()
+
+	// out of range
+	b = NewBuffer(s)
+	require.Error(b.ApplyMergePatch([]byte(`{"id": 9223372036854775808}`)))
+	require.Error(b.ApplyMergePatch([]byte(`{"qty": 2147483648}`)))
+	require.Error(b.ApplyMergePatch([]byte(`{"small": -32769}`)))
+	require.Error(b.ApplyMergePatch([]byte(`{"b": 256}`)))
+	require.Error(b.ApplyMergePatch([]byte(`{"price": 1e39}`)))
+	require.Error(b.ApplyMergePatch([]byte(`{"ids": [1, 9223372036854775808]}`)))
+	require.Error(b.ApplyMergePatch([]byte(`{"lines": [{"id": 9223372036854775808}]}`)))
+	require.Error(b.ApplyMergePatch([]byte(`{"id": 1} {}`)))
+
+	// fraction for an integer field -> error on ToBytes() as before
+	require.NoError(b.ApplyMergePatch([]byte(`{"qty": 1.5}`)))
+	_, err = b.ToBytes()
+	require.Error(err)
+	b.Release()
+
+	require.Zero(GetObjectsInUse())
+}
+
+func TestMergePatch(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(orderSchemeYaml)
+	require.NoError(err)
+	orderBytes := getOrderBytes(t, s)
+
+	bOld := ReadBuffer(orderBytes, s)
+	bNew := ReadBuffer(orderBytes, s)
+	patch, err := MergePatch(bOld, bNew)
+	require.NoError(err)
+	require.JSONEq(`{}`, string(patch))
+
+	bNew.Set("name", nil)
+	bNew.GetMutableObjectArray("lines").At(0).Set("qty", int32(5))
+	require.NoError(bNew.ApplyMergePatch([]byte(`{"article": {"name": "fanta"}}`)))
+	patch, err = MergePatch(bOld, bNew)
+	require.NoError(err)
+	require.JSONEq(`{
+		"name": null,
+		"article": {"name": "fanta"},
+		"lines": [
+			{"qty": 5, "price": 1.5, "article": {"id": 10, "name": "art10"}},
+			{"qty": 2, "price": 2.5, "article": {"id": 20, "name": "art20"}}
+		]
+	}`, string(patch))
+
+	// applying the patch to old gives new
+	require.NoError(bOld.ApplyMergePatch(patch))
+	require.True(bOld.Equal(bNew))
+
+	// from empty
+	patch, err = MergePatch(nil, bNew)
+	require.NoError(err)
+	bEmpty := NewBuffer(s)
+	require.NoError(bEmpty.ApplyMergePatch(patch))
+	require.True(bEmpty.Equal(bNew))
+
+	bEmpty.Release()
+	bOld.Release()
+	bNew.Release()
+	require.Zero(GetObjectsInUse())
+}
@@ -0,0 +1,231 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"fmt"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// MutableObjectArray is an editable array of nested objects. Use Buffer.GetMutableObjectArray() to obtain
+// Modifications are considered on owner's ToBytes(). Unmodified stored elements are copied field by field without creating a Buffer per element
+// Note: Buffers provided to Set(), Insert() and Append() are owned by the array and will be released on owner's Release()
+type MutableObjectArray struct {
+	owner      *Buffer
+	field      *Field
+	elems      []mutableElem
+	reader     *Buffer // used to read unmodified stored elements
+	isReleased bool
+}
+
+type mutableElem struct {
+	buf *Buffer           // != nil -> element is provided by the user or obtained by At()
+	tab flatbuffers.Table // stored element, used if buf == nil
+}
+
+// GetMutableObjectArray returns editable array of nested objects by name
+// Field is unset -> empty array is returned, elements could be appended or inserted
+// Stored elements and modifications made by Set(), Append(), ApplyJSONAndToBytes, ApplyMapBuffer, ApplyMap are considered
+// The array is considered on ToBytes() automatically. Further calls return the same instance until the field is Set() or Append()'ed
+// No such field or the field is not an array of nested objects -> nil
+func (b *Buffer) GetMutableObjectArray(name string) *MutableObjectArray {
+	f, ok := b.Scheme.FieldsMap[name]
+	if !ok || !f.IsArray || f.Ft != FieldTypeObject {
+		return nil
+	}
+	return b.getMutableObjectArray(f)
+}
+
+func (b *Buffer) getMutableObjectArray(f *Field) *MutableObjectArray {
+	b.prepareFieldsToBytes()
+	m := &b.fieldsToBytes[f.Order]
+	if m.hasValue {
+		if res, ok := m.value.(*MutableObjectArray); ok {
+			return res
+		}
+	}
+	res := getMutableObjectArray()
+	res.owner = b
+	res.field = f
+	if !m.hasValue || m.isAppend {
+		if uOffsetT := b.getFieldUOffsetTByOrder(f.Order); uOffsetT != 0 {
+			res.appendStored(b.tab.Bytes, b.tab.Vector(uOffsetT-b.tab.Pos), b.tab.VectorLen(uOffsetT-b.tab.Pos))
+		}
+	}
+	if m.hasValue {
+		switch arr := m.value.(type) {
+		case []*Buffer:
+			for _, buf := range arr {
+				res.elems = append(res.elems, mutableElem{buf: buf})
+			}
+			m.value = nil // elements are owned by res now
+		case *buffersSlice:
+			for _, buf := range arr.Slice {
+				res.elems = append(res.elems, mutableElem{buf: buf})
+			}
+			arr.Slice = arr.Slice[:0] // elements are owned by res now
+		case *ObjectArray:
+			res.appendStored(arr.Buffer.tab.Bytes, arr.start, arr.Len)
+		}
+	}
+	b.set(f, res)
+	return res
+}
+
+func (a *MutableObjectArray) appendStored(bytes []byte, start flatbuffers.UOffsetT, l int) {
+	tab := flatbuffers.Table{Bytes: bytes}
+	for i := 0; i < l; i++ {
+		a.elems = append(a.elems, mutableElem{tab: flatbuffers.Table{
+			Bytes: bytes,
+			Pos:   tab.Indirect(start + flatbuffers.UOffsetT(l-1-i)*flatbuffers.SizeUOffsetT),
+		}})
+	}
+}
+
+// Len returns elements amount
+func (a *MutableObjectArray) Len() int {
+	return len(a.elems)
+}
+
+// At returns element by index. The element could be modified, modifications are considered on owner's ToBytes()
+// Panics if index is out of range
+func (a *MutableObjectArray) At(idx int) *Buffer {
+	a.check(idx, len(a.elems))
+	elem := &a.elems[idx]
+	if elem.buf == nil {
+		elem.buf = NewBuffer(a.field.FieldScheme)
+		elem.buf.tab = elem.tab
+		elem.buf.owner = a.owner
+	}
+	return elem.buf
+}
+
+// Set replaces element by index. Panics if index is out of range
+func (a *MutableObjectArray) Set(idx int, value *Buffer) {
+	a.check(idx, len(a.elems))
+	a.releaseLater(a.elems[idx].buf)
+	a.elems[idx] = a.newElem(value)
+}
+
+// Insert inserts element at index. idx == Len() -> the element is appended. Panics if index is out of range
+func (a *MutableObjectArray) Insert(idx int, value *Buffer) {
+	a.check(idx, len(a.elems)+1)
+	a.elems = append(a.elems, mutableElem{})
+	copy(a.elems[idx+1:], a.elems[idx:])
+	a.elems[idx] = a.newElem(value)
+}
+
+// Append appends element to the end of the array
+func (a *MutableObjectArray) Append(value *Buffer) {
+	a.elems = append(a.elems, a.newElem(value))
+}
+
+// Remove removes element by index. Panics if index is out of range
+func (a *MutableObjectArray) Remove(idx int) {
+	a.check(idx, len(a.elems))
+	a.releaseLater(a.elems[idx].buf)
+	copy(a.elems[idx:], a.elems[idx+1:])
+	a.elems[len(a.elems)-1] = mutableElem{}
+	a.elems = a.elems[:len(a.elems)-1]
+}
+
+// Move moves element from one index to another shifting elements in between. Panics if an index is out of range
+func (a *MutableObjectArray) Move(from, to int) {
+	a.check(from, len(a.elems))
+	a.check(to, len(a.elems))
+	elem := a.elems[from]
+	if from < to {
+		copy(a.elems[from:to], a.elems[from+1:to+1])
+	} else {
+		copy(a.elems[to+1:from+1], a.elems[to:from])
+	}
+	a.elems[to] = elem
+}
+
+// Truncate keeps first `l` elements. Panics if `l` is negative, nothing happens if `l` >= Len()
+func (a *MutableObjectArray) Truncate(l int) {
+	if l < 0 {
+		panic(fmt.Sprintf("negative length: %d", l))
+	}
+	if l >= len(a.elems) {
+		return
+	}
+	for i := l; i < len(a.elems); i++ {
+		a.releaseLater(a.elems[i].buf)
+		a.elems[i] = mutableElem{}
+	}
+	a.elems = a.elems[:l]
+}
+
+// Release returns the array and its elements to the pool
+// Called automatically on owner's Release()
+func (a *MutableObjectArray) Release() {
+	if a.isReleased {
+		return
+	}
+	for i := range a.elems {
+		if a.elems[i].buf != nil {
+			a.elems[i].buf.Release()
+		}
+		a.elems[i] = mutableElem{}
+	}
+	a.elems = a.elems[:0]
+	if a.reader != nil {
+		a.reader.Release()
+		a.reader = nil
+	}
+	a.owner = nil
+	a.field = nil
+	a.isReleased = true
+	putMutableObjectArray(a)
+}
+
+func (a *MutableObjectArray) check(idx int, l int) {
+	if idx < 0 || idx >= l {
+		panic(fmt.Sprintf("index out of range: %d of %d", idx, l))
+	}
+}
+
+func (a *MutableObjectArray) newElem(value *Buffer) mutableElem {
+	if value != nil {
+		value.owner = a.owner
+	}
+	return mutableElem{buf: value}
+}
+
+// releaseLater releases removed element on owner's release because the element could be still in use
+func (a *MutableObjectArray) releaseLater(buf *Buffer) {
+	if buf != nil {
+		a.owner.toRelease = append(a.owner.toRelease, buf)
+	}
+}
+
+// iterate calls `callback` for each element. Unmodified stored elements are read using the single reader Buffer
+func (a *MutableObjectArray) iterate(callback func(elem *Buffer) error) error {
+	for i := range a.elems {
+		elem := &a.elems[i]
+		buf := elem.buf
+		if buf == nil {
+			if len(elem.tab.Bytes) == 0 {
+				return fmt.Errorf("nil element of array field %s is provided. Nils are not supported for array elements", a.field.QualifiedName())
+			}
+			if a.reader == nil {
+				a.reader = NewBuffer(a.field.FieldScheme)
+				a.reader.owner = a.owner
+			}
+			a.reader.releaseFieldsToBytes()
+			a.reader.tab = elem.tab
+			buf = a.reader
+		}
+		if err := callback(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func getLinesQty(t *testing.T, bytes []byte, s *Scheme) []int32 {
+	b := ReadBuffer(bytes, s)
+	defer b.Release()
+	res := []int32{}
+	arr, ok := b.Get("lines").(*ObjectArray)
+	if !ok {
+		return res
+	}
+	for arr.Next() {
+		res = append(res, arr.Buffer.Get("qty").(int32))
+	}
+	return res
+}
+
+func newLine(s *Scheme, qty int32) *Buffer {
+	res := NewBuffer(s.GetNestedScheme("lines"))
+	res.Set("qty", qty)
+	return res
+}
+
+func TestMutableObjectArray(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(orderSchemeYaml)
+	require.NoError(err)
+	orderBytes := getOrderBytes(t, s)
+
+	t.Run("unmodified", func(t *testing.T) {
+		b := ReadBuffer(orderBytes, s)
+		arr := b.GetMutableObjectArray("lines")
+		require.Equal(2, arr.Len())
+		require.Same(arr, b.GetMutableObjectArray("lines"))
+		bytes, err := b.ToBytes()
+		require.NoError(err)
+		require.Equal([]int32{1, 2}, getLinesQty(t, bytes, s))
+
+		// nested objects of the elements are copied too
+		bRead := ReadBuffer(bytes, s)
+		lines := bRead.Get("lines").(*ObjectArray)
+		require.True(lines.Next())
+		require.Equal("art10", lines.Buffer.Get("article").(*Buffer).Get("name"))
+		require.True(lines.Next())
+		require.Equal("art20", lines.Buffer.Get("article").(*Buffer).Get("name"))
+		bRead.Release()
+		b.Release()
+	})
+
+	t.Run("edit", func(t *testing.T) {
+		b := ReadBuffer(orderBytes, s)
+		arr := b.GetMutableObjectArray("lines")
+		arr.At(1).Set("qty", int32(20))
+		arr.Append(newLine(s, 3))
+		arr.Insert(0, newLine(s, 0))
+		bytes, err := b.ToBytes()
+		require.NoError(err)
+		require.Equal([]int32{0, 1, 20, 3}, getLinesQty(t, bytes, s))
+
+		arr.Move(3, 0)
+		bytes, err = b.ToBytes()
+		require.NoError(err)
+		require.Equal([]int32{3, 0, 1, 20}, getLinesQty(t, bytes, s))
+
+		arr.Move(0, 2)
+		bytes, err = b.ToBytes()
+		require.NoError(err)
+		require.Equal([]int32{0, 1, 3, 20}, getLinesQty(t, bytes, s))
+
+		arr.Remove(1)
+		arr.Set(0, newLine(s, 10))
+		bytes, err = b.ToBytes()
+		require.NoError(err)
+		require.Equal([]int32{10, 3, 20}, getLinesQty(t, bytes, s))
+
+		arr.Truncate(5)
+		arr.Truncate(1)
+		bytes, err = b.ToBytes()
+		require.NoError(err)
+		require.Equal([]int32{10}, getLinesQty(t, bytes, s))
+
+		// empty array -> unset
+		arr.Truncate(0)
+		bytes, err = b.ToBytes()
+		require.NoError(err)
+		bRead := ReadBuffer(bytes, s)
+		require.False(bRead.HasValue("lines"))
+		bRead.Release()
+
+		require.Panics(func() { arr.At(0) })
+		require.Panics(func() { arr.Insert(1, nil) })
+		require.Panics(func() { arr.Truncate(-1) })
+		b.Release()
+	})
+
+	t.Run("pending modifications are considered", func(t *testing.T) {
+		b := ReadBuffer(orderBytes, s)
+		bLine := newLine(s, 3)
+		b.Append("lines", []*Buffer{bLine})
+		arr := b.GetMutableObjectArray("lines")
+		require.Equal(3, arr.Len())
+		require.Same(bLine, arr.At(2))
+		arr.Remove(0)
+		bytes, err := b.ToBytes()
+		require.NoError(err)
+		require.Equal([]int32{2, 3}, getLinesQty(t, bytes, s))
+
+		// Set() replaces the mutable array
+		b.Set("lines", []*Buffer{newLine(s, 5)})
+		arr = b.GetMutableObjectArray("lines")
+		require.Equal(1, arr.Len())
+		arr.Append(newLine(s, 6))
+		bytes, err = b.ToBytes()
+		require.NoError(err)
+		require.Equal([]int32{5, 6}, getLinesQty(t, bytes, s))
+		b.Release()
+
+		// ObjectArray provided by Set()
+		b = ReadBuffer(orderBytes, s)
+		bSrc := ReadBuffer(orderBytes, s)
+		b.Set("lines", bSrc.Get("lines"))
+		arr = b.GetMutableObjectArray("lines")
+		arr.Append(newLine(s, 3))
+		bytes, err = b.ToBytes()
+		require.NoError(err)
+		require.Equal([]int32{1, 2, 3}, getLinesQty(t, bytes, s))
+		bSrc.Release()
+		b.Release()
+	})
+
+	t.Run("unset field", func(t *testing.T) {
+		b := NewBuffer(s)
+		arr := b.GetMutableObjectArray("lines")
+		require.Zero(arr.Len())
+		arr.Append(newLine(s, 1))
+		bytes, err := b.ToBytes()
+		require.NoError(err)
+		require.Equal([]int32{1}, getLinesQty(t, bytes, s))
+
+		arr.Append(nil)
+		_, err = b.ToBytes()
+		require.Error(err)
+		b.Release()
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		b := ReadBuffer(orderBytes, s)
+		arr := b.GetMutableObjectArray("lines")
+		arr.Remove(1)
+		arr.At(0).Set("price", float64(3))
+		require.Equal(`{"name":"order","tags":["vip","delivery"],"article":{"id":1,"name":"cola"},"lines":[{"qty":1,"price":3,"article":{"id":10,"name":"art10"}}]}`, string(b.ToJSON()))
+		m := b.ToJSONMap()
+		require.Len(m["lines"], 1)
+		b.Release()
+	})
+
+	t.Run("not an array of objects", func(t *testing.T) {
+		b := NewBuffer(s)
+		require.Nil(b.GetMutableObjectArray("tags"))
+		require.Nil(b.GetMutableObjectArray("article"))
+		require.Nil(b.GetMutableObjectArray("unknown"))
+		b.Release()
+	})
+
+	require.Zero(GetObjectsInUse())
+}
+
+func TestObjectArrayReencodeNested(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(orderSchemeYaml)
+	require.NoError(err)
+	orderBytes := getOrderBytes(t, s)
+
+	// each element of the stored array must be encoded with its own nested objects
+	b := ReadBuffer(orderBytes, s)
+	bSrc := ReadBuffer(orderBytes, s)
+	b.Set("lines", bSrc.Get("lines"))
+	bytes, err := b.ToBytes()
+	require.NoError(err)
+	bRead := ReadBuffer(bytes, s)
+	actual, err := bRead.GetPath("lines[1].article.name")
+	require.NoError(err)
+	require.Equal("art20", actual)
+
+	bRead.Release()
+	bSrc.Release()
+	b.Release()
+	require.Zero(GetObjectsInUse())
+}
+
+func TestObjectArrayNextKeepsNested(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(orderSchemeYaml)
+	require.NoError(err)
+	b := ReadBuffer(getOrderBytes(t, s), s)
+
+	// nested objects of the previous element are still valid after Next() and are released with the array
+	lines := b.Get("lines").(*ObjectArray)
+	require.True(lines.Next())
+	prev := lines.Buffer.Get("article").(*Buffer)
+	require.True(lines.Next())
+	require.Equal("art20", lines.Buffer.Get("article").(*Buffer).Get("name"))
+	require.False(prev.isReleased)
+	require.Equal("art10", prev.Get("name"))
+
+	b.Release()
+	require.Zero(GetObjectsInUse())
+}
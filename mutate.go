@@ -0,0 +1,164 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// MutateInt16 overwrites stored value of int16 field directly in the underlying bytes, no re-encoding is made
+// Returns false if no such field, field type differs, the value is not stored or the field has a pending modification made by Set, Append etc. Use Set() in this case
+// Note: the byte array provided to ReadBuffer() or Reset() is modified
+func (b *Buffer) MutateInt16(name string, value int16) bool {
+	if o := b.getMutableUOffsetT(name, FieldTypeInt16); o != 0 {
+		return b.tab.MutateInt16(o, value)
+	}
+	return false
+}
+
+// MutateInt32 overwrites stored value of int32 field in place. See MutateInt16() for details
+func (b *Buffer) MutateInt32(name string, value int32) bool {
+	if o := b.getMutableUOffsetT(name, FieldTypeInt32); o != 0 {
+		return b.tab.MutateInt32(o, value)
+	}
+	return false
+}
+
+// MutateInt64 overwrites stored value of int64 field in place. See MutateInt16() for details
+func (b *Buffer) MutateInt64(name string, value int64) bool {
+	if o := b.getMutableUOffsetT(name, FieldTypeInt64); o != 0 {
+		return b.tab.MutateInt64(o, value)
+	}
+	return false
+}
+
+// MutateFloat32 overwrites stored value of float32 field in place. See MutateInt16() for details
+func (b *Buffer) MutateFloat32(name string, value float32) bool {
+	if o := b.getMutableUOffsetT(name, FieldTypeFloat32); o != 0 {
+		return b.tab.MutateFloat32(o, value)
+	}
+	return false
+}
+
+// MutateFloat64 overwrites stored value of float64 field in place. See MutateInt16() for details
+func (b *Buffer) MutateFloat64(name string, value float64) bool {
+	if o := b.getMutableUOffsetT(name, FieldTypeFloat64); o != 0 {
+		return b.tab.MutateFloat64(o, value)
+	}
+	return false
+}
+
+// MutateByte overwrites stored value of byte field in place. See MutateInt16() for details
+func (b *Buffer) MutateByte(name string, value byte) bool {
+	if o := b.getMutableUOffsetT(name, FieldTypeByte); o != 0 {
+		return b.tab.MutateByte(o, value)
+	}
+	return false
+}
+
+// MutateBool overwrites stored value of bool field in place. See MutateInt16() for details
+func (b *Buffer) MutateBool(name string, value bool) bool {
+	if o := b.getMutableUOffsetT(name, FieldTypeBool); o != 0 {
+		return b.tab.MutateBool(o, value)
+	}
+	return false
+}
+
+// Mutate overwrites stored value of a scalar field in place. See MutateInt16() for details
+// Value rules are the same as for Set(), e.g. float64 or int is accepted for int32 field if fits. Wrong value -> false
+func (b *Buffer) Mutate(name string, value interface{}) bool {
+	f, ok := b.Scheme.FieldsMap[name]
+	if !ok || f.IsArray {
+		return false
+	}
+	if o := b.getMutableUOffsetT(name, f.Ft); o != 0 {
+		return b.mutate(f, o, value)
+	}
+	return false
+}
+
+// MutateAt overwrites stored element of an array of scalars in place. See Mutate() for details
+// Index is out of range -> false. Arrays of strings and nested objects are not supported -> false
+// Byte array has a FieldTransformer -> false: stored bytes are encoded so a plain value could not be written over them
+func (b *Buffer) MutateAt(name string, idx int, value interface{}) bool {
+	f, ok := b.Scheme.FieldsMap[name]
+	if !ok || !f.IsArray || f.Ft == FieldTypeString || f.Ft == FieldTypeObject || f.transformer != nil || b.hasPendingModification(f) {
+		return false
+	}
+	uOffsetT := b.getFieldUOffsetTByOrder(f.Order)
+	if uOffsetT == 0 {
+		return false
+	}
+	l := b.tab.VectorLen(uOffsetT - b.tab.Pos)
+	if idx < 0 || idx >= l {
+		return false
+	}
+	if f.Ft != FieldTypeByte {
+		idx = l - idx - 1 // elements are stored in reverse order except byte arrays
+	}
+	elemUOffsetT := b.tab.Vector(uOffsetT-b.tab.Pos) + flatbuffers.UOffsetT(idx*fieldTypeSize(f.Ft))
+	return b.mutate(f, elemUOffsetT, value)
+}
+
+func (b *Buffer) mutate(f *Field, o flatbuffers.UOffsetT, value interface{}) bool {
+	switch f.Ft {
+	case FieldTypeInt16:
+		v, ok := toArrayElemOf[int16](f, value)
+		return ok && b.tab.MutateInt16(o, v)
+	case FieldTypeInt32:
+		v, ok := toArrayElemOf[int32](f, value)
+		return ok && b.tab.MutateInt32(o, v)
+	case FieldTypeInt64:
+		v, ok := toArrayElemOf[int64](f, value)
+		return ok && b.tab.MutateInt64(o, v)
+	case FieldTypeFloat32:
+		v, ok := toArrayElemOf[float32](f, value)
+		return ok && b.tab.MutateFloat32(o, v)
+	case FieldTypeFloat64:
+		v, ok := toArrayElemOf[float64](f, value)
+		return ok && b.tab.MutateFloat64(o, v)
+	case FieldTypeByte:
+		v, ok := toArrayElemOf[byte](f, value)
+		return ok && b.tab.MutateByte(o, v)
+	case FieldTypeBool:
+		v, ok := toArrayElemOf[bool](f, value)
+		return ok && b.tab.MutateBool(o, v)
+	}
+	return false
+}
+
+// getMutableUOffsetT returns offset of the stored scalar value which could be mutated in place, 0 otherwise
+func (b *Buffer) getMutableUOffsetT(name string, ft FieldType) flatbuffers.UOffsetT {
+	f, ok := b.Scheme.FieldsMap[name]
+	if !ok || f.IsArray || f.Ft != ft || b.hasPendingModification(f) {
+		return 0
+	}
+	return b.getFieldUOffsetTByOrder(f.Order)
+}
+
+// hasPendingModification returns true if the field was modified by Set, Append, RemoveAt etc. In place mutation will not be considered on ToBytes() then
+func (b *Buffer) hasPendingModification(f *Field) bool {
+	if f.Order >= len(b.fieldsToBytes) {
+		return false
+	}
+	m := &b.fieldsToBytes[f.Order]
+	return m.hasValue || len(m.arrayOps) > 0
+}
+
+func fieldTypeSize(ft FieldType) int {
+	switch ft {
+	case FieldTypeInt16:
+		return flatbuffers.SizeInt16
+	case FieldTypeInt32, FieldTypeFloat32:
+		return flatbuffers.SizeInt32
+	case FieldTypeInt64, FieldTypeFloat64:
+		return flatbuffers.SizeInt64
+	default: // byte, bool
+		return flatbuffers.SizeByte
+	}
+}
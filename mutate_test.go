@@ -0,0 +1,110 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMutate(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(`
+i16: int16
+i32: int32
+i64: int64
+f32: float32
+f64: float64
+b: byte
+bl: bool
+str: string
+unset: int32
+ints..: int32
+bytes..: byte
+strs..: string
+nested:
+  counter: int64
+`)
+	require.NoError(err)
+	b := NewBuffer(s)
+	require.NoError(b.ApplyMap(map[string]interface{}{
+		"i16": float64(1), "i32": float64(2), "i64": float64(3), "f32": float64(4), "f64": float64(5), "b": float64(6), "bl": false,
+		"str": "str", "ints": []interface{}{float64(1), float64(2)}, "bytes": []byte{1, 2}, "strs": []string{"str"},
+		"nested": map[string]interface{}{"counter": float64(1)},
+	}))
+	bytes, err := b.ToBytes()
+	require.NoError(err)
+	b.Release()
+
+	b = ReadBuffer(copyBytes(bytes), s)
+	require.True(b.MutateInt16("i16", 10))
+	require.True(b.MutateInt32("i32", 20))
+	require.True(b.MutateInt64("i64", 30))
+	require.True(b.MutateFloat32("f32", 40))
+	require.True(b.MutateFloat64("f64", 50))
+	require.True(b.MutateByte("b", 60))
+	require.True(b.MutateBool("bl", true))
+	require.True(b.MutateAt("ints", 1, int32(-2)))
+	require.True(b.MutateAt("bytes", 0, float64(11)))
+
+	// no re-encoding needed
+	require.False(b.IsModified())
+	require.True(b.Get("nested").(*Buffer).MutateInt64("counter", 2))
+	require.Equal(int16(10), b.Get("i16"))
+	require.Equal(int32(20), b.Get("i32"))
+	require.Equal(int64(30), b.Get("i64"))
+	require.Equal(float32(40), b.Get("f32"))
+	require.Equal(float64(50), b.Get("f64"))
+	require.Equal(byte(60), b.Get("b"))
+	require.Equal(true, b.Get("bl"))
+	require.Equal([]int32{1, -2}, b.Get("ints"))
+	require.Equal([]byte{11, 2}, b.Get("bytes"))
+
+	// mutations are kept on ToBytes()
+	bytes, err = b.ToBytes()
+	require.NoError(err)
+	bRead := ReadBuffer(bytes, s)
+	require.Equal(int32(20), bRead.Get("i32"))
+	require.Equal(int64(2), bRead.Get("nested").(*Buffer).Get("counter"))
+	bRead.Release()
+
+	// generic
+	require.True(b.Mutate("i32", int32(21)))
+	require.True(b.Mutate("i32", float64(22)))
+	require.Equal(int32(22), b.Get("i32"))
+	require.False(b.Mutate("i32", float64(1.5)))
+	require.False(b.Mutate("i32", "str"))
+	require.False(b.Mutate("i32", int64(1)))
+	require.True(b.Mutate("i32", 23)) // int is accepted as by Set()
+	require.Equal(int32(23), b.Get("i32"))
+	require.False(b.Mutate("i32", math.MaxInt32+1))
+	require.True(b.Mutate("f64", 51))
+	require.Equal(float64(51), b.Get("f64"))
+	require.True(b.MutateAt("bytes", 1, 12))
+	require.False(b.MutateAt("bytes", 1, 256))
+
+	// not applicable -> false, nothing changed
+	require.False(b.MutateInt64("i32", 1))        // wrong type
+	require.False(b.MutateInt32("unset", 1))      // absent slot
+	require.False(b.MutateInt32("unknown", 1))    // unknown field
+	require.False(b.Mutate("str", "str"))         // not a scalar
+	require.False(b.Mutate("ints", int32(1)))     // array
+	require.False(b.MutateAt("ints", 2, 1))       // out of range
+	require.False(b.MutateAt("strs", 0, "str"))   // array of strings
+	require.False(b.MutateAt("i32", 0, int32(1))) // not an array
+	b.Set("i32", int32(1))
+	require.False(b.MutateInt32("i32", 2)) // pending modification
+	b.RemoveAt("ints", 0)
+	require.False(b.MutateAt("ints", 0, int32(1)))
+	require.Equal(int32(23), b.Get("i32"))
+
+	b.Release()
+	require.Zero(GetObjectsInUse())
+}
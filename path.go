@@ -0,0 +1,403 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+var (
+	// ErrMalformedPath is returned if a path string could not be parsed
+	ErrMalformedPath = errors.New("malformed path")
+	// ErrUnknownField is returned if a path refers to a field which does not exist in the Scheme
+	ErrUnknownField = errors.New("unknown field")
+	// ErrPathMismatch is returned if a path does not match the Scheme, e.g. index is applied to a non-array field
+	ErrPathMismatch = errors.New("path does not match the scheme")
+	// ErrIndexOutOfRange is returned if a path refers to an array element which does not exist
+	ErrIndexOutOfRange = errors.New("index out of range")
+)
+
+// PathError describes a failure to compile or to resolve a path
+type PathError struct {
+	Path string
+	Err  error
+}
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("path %s: %v", e.Path, e.Err)
+}
+
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
+
+// Path is a path to a field across nested objects and arrays resolved against a Scheme, e.g. `lines[3].article.id`
+// Compile once using Scheme.CompilePath() and use many times to avoid search by name on each access
+type Path struct {
+	scheme *Scheme
+	str    string
+	steps  []pathStep
+}
+
+type pathStep struct {
+	field *Field
+	index int // < 0 -> no index
+}
+
+// CompilePath parses and resolves the path against the Scheme
+// Path is a dot-separated list of field names. Array element is addressed as `name[index]`
+// Each step except the last one must be a nested object or an element of an array of nested objects
+func (s *Scheme) CompilePath(path string) (*Path, error) {
+	res := &Path{scheme: s, str: path}
+	curScheme := s
+	segments := strings.Split(path, ".")
+	for i, segment := range segments {
+		name, index, err := parsePathSegment(segment)
+		if err != nil {
+			return nil, &PathError{path, err}
+		}
+		if curScheme == nil {
+			return nil, &PathError{path, fmt.Errorf("%w: %s is not a nested object", ErrPathMismatch, segments[i-1])}
+		}
+		f, ok := curScheme.FieldsMap[name]
+		if !ok {
+			return nil, &PathError{path, fmt.Errorf("%w: %s", ErrUnknownField, name)}
+		}
+		if index >= 0 && !f.IsArray {
+			return nil, &PathError{path, fmt.Errorf("%w: %s is not an array", ErrPathMismatch, f.QualifiedName())}
+		}
+		if i < len(segments)-1 && f.IsArray && index < 0 {
+			return nil, &PathError{path, fmt.Errorf("%w: element index of array %s is not specified", ErrPathMismatch, f.QualifiedName())}
+		}
+		res.steps = append(res.steps, pathStep{f, index})
+		curScheme = f.FieldScheme
+	}
+	return res, nil
+}
+
+func parsePathSegment(segment string) (name string, index int, err error) {
+	index = -1
+	name = segment
+	if bracket := strings.IndexByte(segment, '['); bracket >= 0 {
+		if !strings.HasSuffix(segment, "]") {
+			return "", 0, fmt.Errorf("%w: %s", ErrMalformedPath, segment)
+		}
+		name = segment[:bracket]
+		if index, err = strconv.Atoi(segment[bracket+1 : len(segment)-1]); err != nil || index < 0 {
+			return "", 0, fmt.Errorf("%w: wrong index in %s", ErrMalformedPath, segment)
+		}
+	}
+	if len(name) == 0 {
+		return "", 0, fmt.Errorf("%w: empty field name", ErrMalformedPath)
+	}
+	return name, index, nil
+}
+
+// String returns the source path
+func (p *Path) String() string {
+	return p.str
+}
+
+// Get returns value by path. Result types are the same as for Buffer.Get()
+// Nested objects set or modified but not yet encoded by ToBytes() are considered, as well as values provided by Set() for the target field
+// Values provided by Append() are not considered
+// A field along the path is unset -> nil, nil
+func (p *Path) Get(b *Buffer) (interface{}, error) {
+	var elements []*Buffer
+	value, err := p.get(b, &elements)
+	if _, isPooled := value.(IRelease); isPooled {
+		// the result could be an element or could be read from an element so they are released with `b`
+		b.toRelease = append(b.toRelease, toIReleases(elements)...)
+	} else {
+		releaseBuffers(elements)
+	}
+	return value, err
+}
+
+// Has returns true if the path is resolved to a non-nil value
+func (p *Path) Has(b *Buffer) bool {
+	var elements []*Buffer
+	value, err := p.get(b, &elements)
+	releaseBuffers(elements)
+	return err == nil && value != nil
+}
+
+func releaseBuffers(buffers []*Buffer) {
+	for _, b := range buffers {
+		b.Release()
+	}
+}
+
+func toIReleases(buffers []*Buffer) []IRelease {
+	res := make([]IRelease, len(buffers))
+	for i, b := range buffers {
+		res[i] = b
+	}
+	return res
+}
+
+// get returns value by path. Read-only elements of arrays of nested objects created along the path, including the result, are added to `elements`
+// The caller must release them
+func (p *Path) get(b *Buffer, elements *[]*Buffer) (interface{}, error) {
+	cur, err := p.resolveParent(b, false, elements)
+	if err != nil || cur == nil {
+		return nil, err
+	}
+	last := p.steps[len(p.steps)-1]
+	value, err := cur.getPathValue(last, elements)
+	if err != nil {
+		return nil, &PathError{p.str, err}
+	}
+	return value, nil
+}
+
+// Set sets value by path. Value rules are the same as for Buffer.Set()
+// Unset nested object along the path -> an empty one is created
+// Array of nested objects along the path is converted to MutableObjectArray, see Buffer.GetMutableObjectArray()
+func (p *Path) Set(b *Buffer, value interface{}) error {
+	cur, err := p.resolveParent(b, true, nil)
+	if err != nil {
+		return err
+	}
+	last := p.steps[len(p.steps)-1]
+	if last.index < 0 {
+		cur.set(last.field, value)
+		return nil
+	}
+	if last.field.Ft != FieldTypeObject {
+		return &PathError{p.str, fmt.Errorf("%w: modification of an element of array %s is not supported", ErrPathMismatch, last.field.QualifiedName())}
+	}
+	bNested, ok := value.(*Buffer)
+	if !ok || bNested == nil {
+		return &PathError{p.str, fmt.Errorf("%w: nested object required but %#v provided for %s", ErrPathMismatch, value, last.field.QualifiedName())}
+	}
+	if err := cur.setPathArrayElement(last, bNested); err != nil {
+		return &PathError{p.str, err}
+	}
+	return nil
+}
+
+// resolveParent walks through all steps except the last one
+// forModification -> unset nested objects are created, arrays of nested objects are made mutable
+// otherwise read-only elements of arrays of nested objects are added to `elements`
+func (p *Path) resolveParent(b *Buffer, forModification bool, elements *[]*Buffer) (*Buffer, error) {
+	if b.Scheme != p.scheme {
+		return nil, &PathError{p.str, fmt.Errorf("%w: path is compiled for an other scheme", ErrPathMismatch)}
+	}
+	cur := b
+	for _, step := range p.steps[:len(p.steps)-1] {
+		next, err := cur.getPathObject(step, forModification, elements)
+		if err != nil {
+			return nil, &PathError{p.str, err}
+		}
+		if next == nil {
+			return nil, nil
+		}
+		cur = next
+	}
+	return cur, nil
+}
+
+// getPathObject returns nested object or element of array of nested objects
+func (b *Buffer) getPathObject(step pathStep, forModification bool, elements *[]*Buffer) (*Buffer, error) {
+	if step.index < 0 {
+		b.prepareFieldsToBytes()
+		m := &b.fieldsToBytes[step.field.Order]
+		if m.hasValue {
+			if bNested, ok := m.value.(*Buffer); ok {
+				return bNested, nil
+			}
+			if !forModification {
+				return nil, nil
+			}
+		} else if res := b.getByField(step.field); res != nil {
+			return res.(*Buffer), nil
+		} else if !forModification {
+			return nil, nil
+		}
+		bNested := NewBuffer(step.field.FieldScheme)
+		b.set(step.field, bNested)
+		return bNested, nil
+	}
+	return b.getPathArrayElement(step, forModification, elements)
+}
+
+// getPathArrayElement returns element of array of nested objects considering array provided by Set() or Append()
+// forModification -> the array is converted to MutableObjectArray so the element modifications are considered on ToBytes()
+// otherwise element of a stored array or of ObjectArray is a new read-only Buffer which is added to `elements`
+func (b *Buffer) getPathArrayElement(step pathStep, forModification bool, elements *[]*Buffer) (*Buffer, error) {
+	if forModification {
+		arr := b.getMutableObjectArray(step.field)
+		if step.index >= arr.Len() {
+			return nil, fmt.Errorf("%w: %d of %d for %s", ErrIndexOutOfRange, step.index, arr.Len(), step.field.QualifiedName())
+		}
+		return arr.At(step.index), nil
+	}
+	b.prepareFieldsToBytes()
+	m := &b.fieldsToBytes[step.field.Order]
+	idx := step.index
+	storedLen := 0
+	if !m.hasValue || m.isAppend {
+		if uOffsetT := b.getFieldUOffsetTByOrder(step.field.Order); uOffsetT != 0 {
+			storedLen = b.tab.VectorLen(uOffsetT - b.tab.Pos)
+			if idx < storedLen {
+				res := b.getStoredArrayElement(step.field, uOffsetT, idx)
+				*elements = append(*elements, res)
+				return res, nil
+			}
+		}
+		idx -= storedLen
+	}
+	pendingLen := 0
+	if m.hasValue {
+		switch arr := m.value.(type) {
+		case []*Buffer:
+			if pendingLen = len(arr); idx < pendingLen {
+				return arr[idx], nil
+			}
+		case *buffersSlice:
+			if pendingLen = len(arr.Slice); idx < pendingLen {
+				return arr.Slice[idx], nil
+			}
+		case *MutableObjectArray:
+			if pendingLen = arr.Len(); idx < pendingLen {
+				return arr.At(idx), nil
+			}
+		case *ObjectArray:
+			if pendingLen = arr.Len; idx < pendingLen {
+				res := NewBuffer(step.field.FieldScheme)
+				res.tab.Bytes = arr.Buffer.tab.Bytes
+				res.tab.Pos = arr.Buffer.tab.Indirect(arr.start + flatbuffers.UOffsetT(arr.Len-1-idx)*flatbuffers.SizeUOffsetT)
+				*elements = append(*elements, res)
+				return res, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: %d of %d for %s", ErrIndexOutOfRange, step.index, storedLen+pendingLen, step.field.QualifiedName())
+}
+
+// setPathArrayElement replaces an element of array of nested objects
+func (b *Buffer) setPathArrayElement(step pathStep, value *Buffer) error {
+	arr := b.getMutableObjectArray(step.field)
+	if step.index >= arr.Len() {
+		return fmt.Errorf("%w: %d of %d for %s", ErrIndexOutOfRange, step.index, arr.Len(), step.field.QualifiedName())
+	}
+	arr.Set(step.index, value)
+	return nil
+}
+
+// getStoredArrayElement returns read-only element of stored array of nested objects
+func (b *Buffer) getStoredArrayElement(f *Field, arrayUOffsetT flatbuffers.UOffsetT, idx int) *Buffer {
+	l := b.tab.VectorLen(arrayUOffsetT - b.tab.Pos)
+	start := b.tab.Vector(arrayUOffsetT - b.tab.Pos)
+	res := NewBuffer(f.FieldScheme)
+	res.tab.Bytes = b.tab.Bytes
+	res.tab.Pos = b.tab.Indirect(start + flatbuffers.UOffsetT(l-1-idx)*flatbuffers.SizeUOffsetT)
+	return res
+}
+
+// getPathValue returns value of the last path step
+func (b *Buffer) getPathValue(step pathStep, elements *[]*Buffer) (interface{}, error) {
+	f := step.field
+	b.prepareFieldsToBytes()
+	m := &b.fieldsToBytes[f.Order]
+	if step.index < 0 {
+		if m.hasValue && !m.isAppend {
+			return m.getValue(), nil
+		}
+		return b.getByField(f), nil
+	}
+	if f.Ft == FieldTypeObject {
+		return b.getPathArrayElement(step, false, elements)
+	}
+	if m.hasValue || len(m.arrayOps) > 0 {
+		// stored value, value provided by Set() or Append() and array operations, decoded if the field has the transformer
+		arr, err := b.getArrayWithOps(f, m)
+		if err != nil {
+			return nil, err
+		}
+		if arr == nil {
+			return nil, nil
+		}
+		v := reflect.ValueOf(arr)
+		if v.Len() == 0 {
+			return nil, nil // empty array is unset
+		}
+		if step.index >= v.Len() {
+			return nil, fmt.Errorf("%w: %d of %d for %s", ErrIndexOutOfRange, step.index, v.Len(), f.QualifiedName())
+		}
+		return v.Index(step.index).Interface(), nil
+	}
+	uOffsetT := b.getFieldUOffsetTByOrder(f.Order)
+	if uOffsetT == 0 {
+		return nil, nil
+	}
+	if f.Ft == FieldTypeByte {
+		arr := b.getByteArrayByUOffsetT(f, uOffsetT)
+		if arr == nil {
+			return nil, nil // decode failed -> unset
+		}
+		if bytes := arr.Bytes(); step.index < len(bytes) {
+			return bytes[step.index], nil
+		}
+		return nil, fmt.Errorf("%w: %d of %d for %s", ErrIndexOutOfRange, step.index, len(arr.Bytes()), f.QualifiedName())
+	}
+	l := b.tab.VectorLen(uOffsetT - b.tab.Pos)
+	if step.index >= l {
+		return nil, fmt.Errorf("%w: %d of %d for %s", ErrIndexOutOfRange, step.index, l, f.QualifiedName())
+	}
+	switch f.Ft {
+	case FieldTypeInt16:
+		return getImplIInt16Array(b, uOffsetT).At(step.index), nil
+	case FieldTypeInt32:
+		return getImplIInt32Array(b, uOffsetT).At(step.index), nil
+	case FieldTypeInt64:
+		return getImplIInt64Array(b, uOffsetT).At(step.index), nil
+	case FieldTypeFloat32:
+		return getImplIFloat32Array(b, uOffsetT).At(step.index), nil
+	case FieldTypeFloat64:
+		return getImplIFloat64Array(b, uOffsetT).At(step.index), nil
+	case FieldTypeBool:
+		return getImplIBoolArray(b, uOffsetT).At(step.index), nil
+	default:
+		return getImplIStringArray(b, uOffsetT).At(step.index), nil
+	}
+}
+
+// GetPath compiles the path and returns value by it. See Path.Get()
+func (b *Buffer) GetPath(path string) (interface{}, error) {
+	p, err := b.Scheme.CompilePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return p.Get(b)
+}
+
+// SetPath compiles the path and sets value by it. See Path.Set()
+func (b *Buffer) SetPath(path string, value interface{}) error {
+	p, err := b.Scheme.CompilePath(path)
+	if err != nil {
+		return err
+	}
+	return p.Set(b, value)
+}
+
+// HasPath returns true if the path is valid and is resolved to a non-nil value
+func (b *Buffer) HasPath(path string) bool {
+	p, err := b.Scheme.CompilePath(path)
+	if err != nil {
+		return false
+	}
+	return p.Has(b)
+}
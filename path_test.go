@@ -0,0 +1,241 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var orderSchemeYaml = `
+name: string
+tags..: string
+article:
+  id: int64
+  name: string
+lines..:
+  qty: int32
+  price: float64
+  article:
+    Id: int64
+    name: string
+`
+
+func getOrderBytes(t *testing.T, s *Scheme) []byte {
+	b := NewBuffer(s)
+	require.NoError(t, b.ApplyMap(map[string]interface{}{
+		"name":    "order",
+		"tags":    []interface{}{"vip", "delivery"},
+		"article": map[string]interface{}{"id": float64(1), "name": "cola"},
+		"lines": []interface{}{
+			map[string]interface{}{"qty": float64(1), "price": 1.5, "article": map[string]interface{}{"id": float64(10), "name": "art10"}},
+			map[string]interface{}{"qty": float64(2), "price": 2.5, "article": map[string]interface{}{"id": float64(20), "name": "art20"}},
+		},
+	}))
+	bytes, err := b.ToBytes()
+	require.NoError(t, err)
+	bytes = copyBytes(bytes)
+	b.Release()
+	return bytes
+}
+
+func TestCompilePath(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(orderSchemeYaml)
+	require.NoError(err)
+
+	p, err := s.CompilePath("lines[1].article.id")
+	require.NoError(err)
+	require.Equal("lines[1].article.id", p.String())
+
+	for path, expectedErr := range map[string]error{
+		"":                    ErrMalformedPath,
+		"lines[1]..id":        ErrMalformedPath,
+		"lines[x].qty":        ErrMalformedPath,
+		"lines[-1].qty":       ErrMalformedPath,
+		"lines[1.qty":         ErrMalformedPath,
+		"unknown":             ErrUnknownField,
+		"lines[0].unknown":    ErrUnknownField,
+		"name[0]":             ErrPathMismatch,
+		"name.id":             ErrPathMismatch,
+		"lines.qty":           ErrPathMismatch,
+		"article[0].id":       ErrPathMismatch,
+		"lines[0].qty.nested": ErrPathMismatch,
+	} {
+		_, err := s.CompilePath(path)
+		require.ErrorIs(err, expectedErr, path)
+		var pathErr *PathError
+		require.ErrorAs(err, &pathErr)
+		require.Equal(path, pathErr.Path)
+	}
+}
+
+func TestGetPath(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(orderSchemeYaml)
+	require.NoError(err)
+	b := ReadBuffer(getOrderBytes(t, s), s)
+
+	for path, expected := range map[string]interface{}{
+		"name":                  "order",
+		"tags[1]":               "delivery",
+		"article.id":            int64(1),
+		"lines[0].qty":          int32(1),
+		"lines[1].price":        2.5,
+		"lines[1].article.id":   int64(20),
+		"lines[0].article.name": "art10",
+	} {
+		actual, err := b.GetPath(path)
+		require.NoError(err, path)
+		require.Equal(expected, actual, path)
+		require.True(b.HasPath(path), path)
+	}
+
+	line, err := b.GetPath("lines[1]")
+	require.NoError(err)
+	require.Equal(int32(2), line.(*Buffer).Get("qty"))
+	article, err := b.GetPath("lines[1].article")
+	require.NoError(err)
+
+	// read-only elements along the path are released right after the read
+	toRelease := len(b.toRelease)
+	inUse := GetObjectsInUse()
+	for i := 0; i < 10; i++ {
+		actual, err := b.GetPath("lines[1].article.id")
+		require.NoError(err)
+		require.Equal(int64(20), actual)
+		require.True(b.HasPath("lines[0].qty"))
+		require.True(b.HasPath("lines[0].article"))
+	}
+	require.Equal(toRelease, len(b.toRelease))
+	require.Equal(inUse, GetObjectsInUse())
+	require.Equal(int32(2), line.(*Buffer).Get("qty"))
+	require.Equal(int64(20), article.(*Buffer).Get("id"))
+
+	_, err = b.GetPath("lines[2].qty")
+	require.ErrorIs(err, ErrIndexOutOfRange)
+	require.False(b.HasPath("lines[2].qty"))
+	_, err = b.GetPath("tags[2]")
+	require.ErrorIs(err, ErrIndexOutOfRange)
+	require.False(b.HasPath("unknown"))
+
+	// pending Append() and array operations are considered
+	b.Append("tags", []string{"last"})
+	actual, err := b.GetPath("tags[2]")
+	require.NoError(err)
+	require.Equal("last", actual)
+	b.RemoveAt("tags", 0)
+	actual, err = b.GetPath("tags[0]")
+	require.NoError(err)
+	require.Equal("delivery", actual)
+	_, err = b.GetPath("tags[2]")
+	require.ErrorIs(err, ErrIndexOutOfRange)
+	b.InsertAt("tags", 0, 42)
+	_, err = b.GetPath("tags[0]")
+	require.Error(err)
+
+	// compiled path
+	p, err := s.CompilePath("lines[1].article.id")
+	require.NoError(err)
+	actual, err = p.Get(b)
+	require.NoError(err)
+	require.Equal(int64(20), actual)
+
+	// path of an other scheme
+	sOther, err := YamlToScheme(orderSchemeYaml)
+	require.NoError(err)
+	bOther := NewBuffer(sOther)
+	_, err = p.Get(bOther)
+	require.ErrorIs(err, ErrPathMismatch)
+	bOther.Release()
+
+	// unset along the path -> nil
+	b.Release()
+	b = NewBuffer(s)
+	actual, err = b.GetPath("article.id")
+	require.NoError(err)
+	require.Nil(actual)
+	require.False(b.HasPath("article.id"))
+	_, err = b.GetPath("lines[0].qty")
+	require.ErrorIs(err, ErrIndexOutOfRange)
+
+	b.Release()
+	require.Zero(GetObjectsInUse())
+}
+
+func TestSetPath(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(orderSchemeYaml)
+	require.NoError(err)
+	b := ReadBuffer(getOrderBytes(t, s), s)
+
+	// nested objects, pending modifications are considered
+	require.NoError(b.SetPath("article.id", int64(2)))
+	actual, err := b.GetPath("article.id")
+	require.NoError(err)
+	require.Equal(int64(2), actual)
+	require.NoError(b.SetPath("name", "order2"))
+	actual, err = b.GetPath("name")
+	require.NoError(err)
+	require.Equal("order2", actual)
+
+	// elements of scalar arrays could not be modified
+	require.ErrorIs(b.SetPath("tags[0]", "str"), ErrPathMismatch)
+
+	// appended elements could be modified
+	bLine := NewBuffer(s.GetNestedScheme("lines"))
+	bLine.Set("qty", int32(3))
+	b.Append("lines", []*Buffer{bLine})
+	require.NoError(b.SetPath("lines[2].article.id", int64(30)))
+	actual, err = b.GetPath("lines[2].article.id")
+	require.NoError(err)
+	require.Equal(int64(30), actual)
+	actual, err = b.GetPath("lines[0].qty")
+	require.NoError(err)
+	require.Equal(int32(1), actual)
+	require.ErrorIs(b.SetPath("lines[3].qty", int32(1)), ErrIndexOutOfRange)
+
+	// stored elements could be modified
+	require.NoError(b.SetPath("lines[0].qty", int32(5)))
+	actual, err = b.GetPath("lines[0].qty")
+	require.NoError(err)
+	require.Equal(int32(5), actual)
+
+	bLineNew := NewBuffer(s.GetNestedScheme("lines"))
+	bLineNew.Set("qty", int32(4))
+	bLineNew.Set("article", NewBuffer(s.GetNestedScheme("lines").GetNestedScheme("article")))
+	require.NoError(b.SetPath("lines[2]", bLineNew))
+	require.NoError(b.SetPath("lines[2].article.id", int64(40)))
+	require.Error(b.SetPath("lines[2]", "str"))
+
+	require.NoError(b.CommitChanges())
+	expected := map[string]interface{}{
+		"name":                "order2",
+		"article.id":          int64(2),
+		"lines[0].qty":        int32(5),
+		"lines[1].qty":        int32(2),
+		"lines[2].qty":        int32(4),
+		"lines[2].article.id": int64(40),
+	}
+	for path, expectedValue := range expected {
+		actual, err := b.GetPath(path)
+		require.NoError(err)
+		require.Equal(expectedValue, actual, path)
+	}
+
+	// unset nested object is created
+	b.Release()
+	b = NewBuffer(s)
+	require.NoError(b.SetPath("article.name", "str"))
+	require.NoError(b.CommitChanges())
+	require.Equal("str", b.Get("article").(*Buffer).Get("name"))
+
+	b.Release()
+	require.Zero(GetObjectsInUse())
+}
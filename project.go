@@ -0,0 +1,174 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"strings"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// projection is a tree of selected fields by field order. nil subtree -> the whole field is selected
+type projection map[int]projection
+
+// Project returns bytes of the Buffer's Scheme which contain only fields by provided paths
+// Path is a dot-separated list of field names, e.g. `article.name`. Path through an array of nested objects selects the field of each element,
+// e.g. `lines.article.id`. Path to a nested object selects the whole object
+// Values are copied from stored bytes. Pending modifications are considered, error is returned if they could not be encoded
+// Error is *PathError which wraps ErrMalformedPath, ErrUnknownField or ErrPathMismatch
+func (b *Buffer) Project(paths ...string) ([]byte, error) {
+	p, err := compileProjection(b.Scheme, paths)
+	if err != nil {
+		return nil, err
+	}
+	committed, release, err := b.committedView()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	bl := flatbuffers.NewBuilder(0)
+	uOffsetT := committed.encodeProjection(bl, p)
+	if uOffsetT == 0 {
+		return nil, nil
+	}
+	bl.Finish(uOffsetT)
+	return bl.FinishedBytes(), nil
+}
+
+// ToJSONFields is an analogue of ToJSON() which writes only fields by provided paths, see Project()
+func (b *Buffer) ToJSONFields(paths ...string) ([]byte, error) {
+	bytes, err := b.Project(paths...)
+	if err != nil {
+		return nil, err
+	}
+	projected := ReadBuffer(bytes, b.Scheme)
+	defer projected.Release()
+	return projected.toJSON(nil), nil
+}
+
+func compileProjection(s *Scheme, paths []string) (projection, error) {
+	res := projection{}
+	fields := []*Field{}
+	for _, path := range paths {
+		fields = fields[:0]
+		curScheme := s
+		for _, name := range strings.Split(path, ".") {
+			if len(name) == 0 {
+				return nil, &PathError{Path: path, Err: ErrMalformedPath}
+			}
+			if curScheme == nil {
+				return nil, &PathError{Path: path, Err: ErrPathMismatch}
+			}
+			f, ok := curScheme.FieldsMap[name]
+			if !ok {
+				return nil, &PathError{Path: path, Err: ErrUnknownField}
+			}
+			fields = append(fields, f)
+			curScheme = f.FieldScheme
+		}
+		cur := res
+		for i, f := range fields {
+			sub, isSelected := cur[f.Order]
+			if isSelected && sub == nil {
+				break // the whole field is selected already
+			}
+			if i == len(fields)-1 {
+				cur[f.Order] = nil
+				break
+			}
+			if !isSelected {
+				sub = projection{}
+				cur[f.Order] = sub
+			}
+			cur = sub
+		}
+	}
+	return res, nil
+}
+
+// encodeProjection encodes selected fields of the stored table. Returns 0 if there is nothing to encode
+func (b *Buffer) encodeProjection(bl *flatbuffers.Builder, p projection) flatbuffers.UOffsetT {
+	if len(b.tab.Bytes) == 0 {
+		return 0
+	}
+	offsets := make([]flatbuffers.UOffsetT, len(b.Scheme.Fields))
+	for _, f := range b.Scheme.Fields {
+		sub, ok := p[f.Order]
+		if !ok {
+			continue
+		}
+		uOffsetT := b.getFieldUOffsetTByOrder(f.Order)
+		if uOffsetT == 0 {
+			continue
+		}
+		switch {
+		case f.IsArray && f.Ft == FieldTypeObject && sub != nil:
+			offsets[f.Order] = b.encodeProjectionArray(bl, f, uOffsetT, sub)
+		case f.IsArray:
+			offsets[f.Order] = b.copyArray(bl, uOffsetT, f)
+		case f.Ft == FieldTypeObject:
+			nested := b.readNested(f, b.tab.Indirect(uOffsetT))
+			nested.owner = b
+			if sub == nil {
+				offsets[f.Order], _ = nested.encodeBuffer(bl) // no errors should be here
+			} else {
+				offsets[f.Order] = nested.encodeProjection(bl, sub)
+			}
+			nested.Release()
+		case f.Ft == FieldTypeString:
+			offsets[f.Order] = bl.CreateByteString(b.tab.ByteVector(uOffsetT))
+		}
+	}
+
+	isStarted := false
+	beforePrepend := func() {
+		if !isStarted {
+			bl.StartObject(len(b.Scheme.Fields))
+			isStarted = true
+		}
+	}
+	for _, f := range b.Scheme.Fields {
+		if _, ok := p[f.Order]; !ok {
+			continue
+		}
+		if offsets[f.Order] != 0 {
+			beforePrepend()
+			bl.PrependUOffsetTSlot(f.Order, offsets[f.Order], 0)
+		} else if !f.IsArray && f.Ft != FieldTypeString && f.Ft != FieldTypeObject {
+			copyFixedSizeValue(bl, b, f, beforePrepend)
+		}
+	}
+	if isStarted {
+		return bl.EndObject()
+	}
+	return 0
+}
+
+// encodeProjectionArray encodes selected fields of each element of the stored array of nested objects
+// Element without selected fields is encoded as an object without fields
+func (b *Buffer) encodeProjectionArray(bl *flatbuffers.Builder, f *Field, arrayUOffsetT flatbuffers.UOffsetT, p projection) flatbuffers.UOffsetT {
+	l := b.tab.VectorLen(arrayUOffsetT - b.tab.Pos)
+	vector := b.tab.Vector(arrayUOffsetT - b.tab.Pos)
+	elems := make([]flatbuffers.UOffsetT, l)
+	elem := b.readNested(f, 0)
+	elem.owner = b
+	for i := range elems {
+		elem.tab.Pos = b.tab.Indirect(vector + flatbuffers.UOffsetT(i)*flatbuffers.SizeUOffsetT)
+		if elems[i] = elem.encodeProjection(bl, p); elems[i] == 0 {
+			bl.StartObject(0)
+			elems[i] = bl.EndObject()
+		}
+	}
+	elem.Release()
+	// stored order of elements is kept
+	bl.StartVector(flatbuffers.SizeUOffsetT, l, flatbuffers.SizeUOffsetT)
+	for i := l - 1; i >= 0; i-- {
+		bl.PrependUOffsetT(elems[i])
+	}
+	return bl.EndVector(l)
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProject(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(orderSchemeYaml)
+	require.NoError(err)
+	orderBytes := getOrderBytes(t, s)
+	b := ReadBuffer(orderBytes, s)
+
+	for paths, expectedJSON := range map[string]string{
+		"name":                             `{"name": "order"}`,
+		"name,tags":                        `{"name": "order", "tags": ["vip", "delivery"]}`,
+		"article.name":                     `{"article": {"name": "cola"}}`,
+		"article.name,article":             `{"article": {"id": 1, "name": "cola"}}`,
+		"article,article.name":             `{"article": {"id": 1, "name": "cola"}}`,
+		"lines.qty":                        `{"lines": [{"qty": 1}, {"qty": 2}]}`,
+		"lines.article.name,lines.price":   `{"lines": [{"price": 1.5, "article": {"name": "art10"}}, {"price": 2.5, "article": {"name": "art20"}}]}`,
+		"lines":                            `{"lines": [{"qty": 1, "price": 1.5, "article": {"id": 10, "name": "art10"}}, {"qty": 2, "price": 2.5, "article": {"id": 20, "name": "art20"}}]}`,
+		"name,article.id,lines.article.id": `{"name": "order", "article": {"id": 1}, "lines": [{"article": {"id": 10}}, {"article": {"id": 20}}]}`,
+	} {
+		pathsList := strings.Split(paths, ",")
+		projectedJSON, err := b.ToJSONFields(pathsList...)
+		require.NoError(err, paths)
+		require.JSONEq(expectedJSON, string(projectedJSON), paths)
+
+		bytes, err := b.Project(pathsList...)
+		require.NoError(err)
+		require.NoError(Verify(bytes, s))
+		bProjected := ReadBuffer(bytes, s)
+		require.JSONEq(expectedJSON, string(bProjected.ToJSON()), paths)
+		bProjected.Release()
+	}
+
+	// pending modifications are considered, elements without selected fields are kept. Arrays are appended on ApplyMap()
+	require.NoError(b.ApplyMap(map[string]interface{}{"lines": []interface{}{
+		map[string]interface{}{"qty": float64(1)},
+		map[string]interface{}{"qty": float64(2), "price": 3.5},
+	}}))
+	projectedJSON, err := b.ToJSONFields("lines.price")
+	require.NoError(err)
+	require.JSONEq(`{"lines": [{"price": 1.5}, {"price": 2.5}, {}, {"price": 3.5}]}`, string(projectedJSON))
+
+	// nothing selected
+	bytes, err := b.Project()
+	require.NoError(err)
+	require.Nil(bytes)
+	projectedJSON, err = b.ToJSONFields("name")
+	require.NoError(err)
+	require.JSONEq(`{"name": "order"}`, string(projectedJSON))
+	b.Release()
+	b = NewBuffer(s)
+	projectedJSON, err = b.ToJSONFields("name")
+	require.NoError(err)
+	require.JSONEq(`{}`, string(projectedJSON))
+	b.Release()
+
+	// errors
+	b = ReadBuffer(orderBytes, s)
+	for path, expectedErr := range map[string]error{
+		"":              ErrMalformedPath,
+		"article.":      ErrMalformedPath,
+		"unknown":       ErrUnknownField,
+		"article.qty":   ErrUnknownField,
+		"name.id":       ErrPathMismatch,
+		"lines.qty.val": ErrPathMismatch,
+	} {
+		_, err := b.Project("name", path)
+		require.ErrorIs(err, expectedErr, path)
+		var pathErr *PathError
+		require.True(errors.As(err, &pathErr))
+		require.Equal(path, pathErr.Path)
+	}
+	b.Release()
+
+	require.Zero(GetObjectsInUse())
+}
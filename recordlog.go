@@ -0,0 +1,301 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// Record log is a data file of size-prefixed records (see StreamWriter) and a sidecar index file `<data file>.idx` which contains uint64
+// little-endian offsets of records in the data file. Record ID is the number of the record in the log starting from 0
+// Data is written before the index, so the index could miss the last records after a crash. It is fixed on open by scanning size prefixes of
+// records after the last indexed one. Broken index is rebuilt by scanning the whole data file. Truncated tail record is cut off
+// Indexed offsets are trusted on open, only their order and the last record are checked. Size prefix of a record is checked against the index
+// on Read(), mismatch -> ErrBrokenIndex, use RebuildIndex()
+
+// IndexFileExt is appended to the data file name to get the index file name
+const IndexFileExt = ".idx"
+
+const indexEntrySize = 8
+
+var (
+	// ErrRecordNotFound is returned if there is no record with the provided ID
+	ErrRecordNotFound = errors.New("record not found")
+	// ErrBrokenIndex is returned by Read() if the record size prefix does not match the index
+	ErrBrokenIndex = errors.New("broken index")
+)
+
+// RecordLog is an append-only file of records with random access by record ID
+// Goroutine-safe: records could be read concurrently with each other and with Append()
+type RecordLog struct {
+	scheme  *Scheme
+	opts    readOptions
+	data    *os.File
+	index   *os.File
+	offsets []int64
+	size    int64 // end of the last record
+	sw      *StreamWriter
+	written int64 // bytes written by sw for the appended record
+	entry   [indexEntrySize]byte
+	lock    sync.RWMutex
+}
+
+type recordLogWriter struct {
+	rl *RecordLog
+}
+
+func (w recordLogWriter) Write(p []byte) (int, error) {
+	n, err := w.rl.data.WriteAt(p, w.rl.size+w.rl.written)
+	w.rl.written += int64(n)
+	return n, err
+}
+
+// OpenRecordLog opens or creates the record log at the data file path. The index is restored if it is missing or broken
+// Records are read using the Scheme and checked according to `opts`
+func OpenRecordLog(path string, scheme *Scheme, opts ...ReadOption) (*RecordLog, error) {
+	data, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	index, err := os.OpenFile(path+IndexFileExt, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		data.Close()
+		return nil, err
+	}
+	rl := &RecordLog{
+		scheme: scheme,
+		opts:   newReadOptions(opts),
+		data:   data,
+		index:  index,
+	}
+	rl.sw = NewStreamWriter(recordLogWriter{rl})
+	if err := rl.restore(); err != nil {
+		rl.Close()
+		return nil, err
+	}
+	return rl, nil
+}
+
+// restore loads the index, indexes records which are written after the last indexed one and cuts off the truncated tail record
+func (rl *RecordLog) restore() error {
+	dataSize, err := fileSize(rl.data)
+	if err != nil {
+		return err
+	}
+	indexSize, err := fileSize(rl.index)
+	if err != nil {
+		return err
+	}
+	indexBytes := make([]byte, indexSize)
+	if _, err := rl.index.ReadAt(indexBytes, 0); err != nil && err != io.EOF {
+		return err
+	}
+	if err := rl.loadIndex(indexBytes, dataSize); err != nil {
+		return rl.RebuildIndex()
+	}
+	indexed := len(rl.offsets)
+	if err := rl.scan(dataSize); err != nil {
+		return err
+	}
+	return rl.writeIndex(indexed)
+}
+
+// loadIndex reads offsets from the index file. Error -> the index is broken
+// Offsets are trusted to avoid reading each record on open: only the order of offsets and the last record are checked against the data file.
+// Records after the last indexed one are indexed by scan()
+func (rl *RecordLog) loadIndex(indexBytes []byte, dataSize int64) error {
+	if len(indexBytes)%indexEntrySize != 0 {
+		return fmt.Errorf("index size %d is not a multiple of %d", len(indexBytes), indexEntrySize)
+	}
+	rl.offsets = rl.offsets[:0]
+	rl.size = 0
+	for i := 0; i < len(indexBytes); i += indexEntrySize {
+		offset := int64(binary.LittleEndian.Uint64(indexBytes[i:]))
+		if offset < rl.size {
+			return fmt.Errorf("record %d offset %d, expected at least %d", len(rl.offsets), offset, rl.size)
+		}
+		rl.offsets = append(rl.offsets, offset)
+		rl.size = offset + flatbuffers.SizeUint32 // at least the size prefix
+	}
+	if len(rl.offsets) == 0 {
+		return nil
+	}
+	recordEnd, err := rl.recordEnd(rl.offsets[len(rl.offsets)-1], dataSize)
+	if err != nil {
+		return err
+	}
+	rl.size = recordEnd
+	return nil
+}
+
+// recordEnd reads the size prefix of the record at `offset` and returns the position after the record
+func (rl *RecordLog) recordEnd(offset int64, dataSize int64) (int64, error) {
+	prefix := [flatbuffers.SizeUint32]byte{}
+	if offset+int64(len(prefix)) > dataSize {
+		return 0, fmt.Errorf("%w: size prefix at offset %d", ErrTruncatedRecord, offset)
+	}
+	if _, err := rl.data.ReadAt(prefix[:], offset); err != nil {
+		return 0, err
+	}
+	res := offset + int64(len(prefix)) + int64(binary.LittleEndian.Uint32(prefix[:]))
+	if res > dataSize {
+		return 0, fmt.Errorf("%w: record at offset %d", ErrTruncatedRecord, offset)
+	}
+	return res, nil
+}
+
+// scan indexes records located after the last indexed one. The truncated tail record is cut off
+func (rl *RecordLog) scan(dataSize int64) error {
+	for rl.size < dataSize {
+		recordEnd, err := rl.recordEnd(rl.size, dataSize)
+		if err != nil {
+			if !errors.Is(err, ErrTruncatedRecord) {
+				return err
+			}
+			return rl.data.Truncate(rl.size)
+		}
+		rl.offsets = append(rl.offsets, rl.size)
+		rl.size = recordEnd
+	}
+	return nil
+}
+
+// writeIndex writes offsets of records starting from `from` to the index file
+func (rl *RecordLog) writeIndex(from int) error {
+	if from == len(rl.offsets) {
+		return nil
+	}
+	bytes := make([]byte, 0, (len(rl.offsets)-from)*indexEntrySize)
+	for _, offset := range rl.offsets[from:] {
+		bytes = binary.LittleEndian.AppendUint64(bytes, uint64(offset))
+	}
+	_, err := rl.index.WriteAt(bytes, int64(from)*indexEntrySize)
+	return err
+}
+
+// RebuildIndex rewrites the index file by scanning size prefixes of all records of the data file
+func (rl *RecordLog) RebuildIndex() error {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	dataSize, err := fileSize(rl.data)
+	if err != nil {
+		return err
+	}
+	rl.offsets = rl.offsets[:0]
+	rl.size = 0
+	if err := rl.scan(dataSize); err != nil {
+		return err
+	}
+	if err := rl.index.Truncate(0); err != nil {
+		return err
+	}
+	return rl.writeIndex(0)
+}
+
+// Append writes the Buffer as ToBytes() does and returns ID of the new record
+func (rl *RecordLog) Append(b *Buffer) (int, error) {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	return rl.append(func() error { return rl.sw.Write(b) })
+}
+
+// AppendBytes writes bytes got from ToBytes() and returns ID of the new record
+func (rl *RecordLog) AppendBytes(bytes []byte) (int, error) {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	return rl.append(func() error { return rl.sw.WriteBytes(bytes) })
+}
+
+func (rl *RecordLog) append(write func() error) (int, error) {
+	rl.written = 0
+	if err := write(); err != nil {
+		if rl.written > 0 {
+			// partially written record must not be considered as records on next open
+			err = errors.Join(err, rl.data.Truncate(rl.size))
+		}
+		return 0, err
+	}
+	recordEnd := rl.size + rl.written
+	id := len(rl.offsets)
+	binary.LittleEndian.PutUint64(rl.entry[:], uint64(rl.size))
+	if _, err := rl.index.WriteAt(rl.entry[:], int64(id)*indexEntrySize); err != nil {
+		return 0, err
+	}
+	rl.offsets = append(rl.offsets, rl.size)
+	rl.size = recordEnd
+	return id, nil
+}
+
+// Read reads the record by ID. The result must be released by the caller
+func (rl *RecordLog) Read(id int) (*Buffer, error) {
+	rl.lock.RLock()
+	if id < 0 || id >= len(rl.offsets) {
+		rl.lock.RUnlock()
+		return nil, fmt.Errorf("%w: %d of %d", ErrRecordNotFound, id, len(rl.offsets))
+	}
+	start := rl.offsets[id]
+	end := rl.size
+	if id+1 < len(rl.offsets) {
+		end = rl.offsets[id+1]
+	}
+	rl.lock.RUnlock()
+	bytes := make([]byte, end-start)
+	if _, err := rl.data.ReadAt(bytes, start); err != nil {
+		return nil, err
+	}
+	if size := binary.LittleEndian.Uint32(bytes); int64(size) != end-start-flatbuffers.SizeUint32 {
+		return nil, fmt.Errorf("%w: record %d size %d, expected %d", ErrBrokenIndex, id, size, end-start-flatbuffers.SizeUint32)
+	}
+	return rl.opts.read(bytes[flatbuffers.SizeUint32:], rl.scheme)
+}
+
+// NewStreamReader returns StreamReader which iterates over all records. Record ID is the number of the Next() call starting from 0
+// Records appended after the StreamReader is created are not iterated
+func (rl *RecordLog) NewStreamReader() *StreamReader {
+	rl.lock.RLock()
+	defer rl.lock.RUnlock()
+	res := NewStreamReader(io.NewSectionReader(rl.data, 0, rl.size), rl.scheme)
+	res.MaxRecordSize = int(rl.size)
+	res.opts = rl.opts
+	return res
+}
+
+// Len returns count of records
+func (rl *RecordLog) Len() int {
+	rl.lock.RLock()
+	defer rl.lock.RUnlock()
+	return len(rl.offsets)
+}
+
+// Sync commits data and index files to the stable storage
+func (rl *RecordLog) Sync() error {
+	if err := rl.data.Sync(); err != nil {
+		return err
+	}
+	return rl.index.Sync()
+}
+
+// Close closes data and index files
+func (rl *RecordLog) Close() error {
+	return errors.Join(rl.data.Close(), rl.index.Close())
+}
+
+func fileSize(f *os.File) (int64, error) {
+	stat, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return stat.Size(), nil
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordLog(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(orderSchemeYaml)
+	require.NoError(err)
+	orderBytes := getOrderBytes(t, s)
+	path := filepath.Join(t.TempDir(), "orders.log")
+
+	rl, err := OpenRecordLog(path, s)
+	require.NoError(err)
+	require.Zero(rl.Len())
+	b := ReadBuffer(orderBytes, s)
+	for i, name := range []string{"order0", "order1", "order2"} {
+		b.Set("name", name)
+		id, err := rl.Append(b)
+		require.NoError(err)
+		require.Equal(i, id)
+	}
+	b.Release()
+	id, err := rl.AppendBytes(nil)
+	require.NoError(err)
+	require.Equal(3, id)
+	require.Equal(4, rl.Len())
+
+	expectedNames := []interface{}{"order0", "order1", "order2", nil}
+	checkRecords := func(rl *RecordLog) {
+		t.Helper()
+		require.Equal(len(expectedNames), rl.Len())
+		for id := len(expectedNames) - 1; id >= 0; id-- {
+			b, err := rl.Read(id)
+			require.NoError(err)
+			require.Equal(expectedNames[id], b.Get("name"))
+			b.Release()
+		}
+		sr := rl.NewStreamReader()
+		id := 0
+		for ; sr.Next(); id++ {
+			require.Equal(expectedNames[id], sr.Buffer().Get("name"))
+		}
+		require.NoError(sr.Err())
+		require.Equal(len(expectedNames), id)
+		sr.Release()
+	}
+	checkRecords(rl)
+	_, err = rl.Read(4)
+	require.ErrorIs(err, ErrRecordNotFound)
+	_, err = rl.Read(-1)
+	require.ErrorIs(err, ErrRecordNotFound)
+	require.NoError(rl.Sync())
+	require.NoError(rl.Close())
+
+	reopen := func() {
+		t.Helper()
+		rl, err := OpenRecordLog(path, s, WithVerification(DefaultVerifyOptions))
+		require.NoError(err)
+		checkRecords(rl)
+		require.NoError(rl.Close())
+	}
+	reopen()
+	indexBytes, err := os.ReadFile(path + IndexFileExt)
+	require.NoError(err)
+	require.Len(indexBytes, 4*indexEntrySize)
+
+	// index misses the last records
+	require.NoError(os.WriteFile(path+IndexFileExt, indexBytes[:indexEntrySize], 0644))
+	reopen()
+	// missing index
+	require.NoError(os.Remove(path + IndexFileExt))
+	reopen()
+	// broken index
+	require.NoError(os.WriteFile(path+IndexFileExt, []byte{1, 2, 3}, 0644))
+	reopen()
+	brokenIndex := copyBytes(indexBytes)
+	binary.LittleEndian.PutUint64(brokenIndex[2*indexEntrySize:], 0) // offsets are not ordered
+	require.NoError(os.WriteFile(path+IndexFileExt, brokenIndex, 0644))
+	reopen()
+	indexBytesRestored, err := os.ReadFile(path + IndexFileExt)
+	require.NoError(err)
+	require.Equal(indexBytes, indexBytesRestored)
+
+	// offsets are trusted on open, wrong offset is detected on Read()
+	brokenIndex = copyBytes(indexBytes)
+	binary.LittleEndian.PutUint64(brokenIndex[indexEntrySize:], binary.LittleEndian.Uint64(indexBytes[indexEntrySize:])+4)
+	require.NoError(os.WriteFile(path+IndexFileExt, brokenIndex, 0644))
+	rl, err = OpenRecordLog(path, s)
+	require.NoError(err)
+	_, err = rl.Read(0)
+	require.ErrorIs(err, ErrBrokenIndex)
+	_, err = rl.Read(1)
+	require.ErrorIs(err, ErrBrokenIndex)
+	require.NoError(rl.RebuildIndex())
+	checkRecords(rl)
+	require.NoError(rl.Close())
+	indexBytesRestored, err = os.ReadFile(path + IndexFileExt)
+	require.NoError(err)
+	require.Equal(indexBytes, indexBytesRestored)
+
+	// truncated tail record is cut off
+	dataBytes, err := os.ReadFile(path)
+	require.NoError(err)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(err)
+	_, err = f.Write([]byte{100, 0, 0, 0, 1, 2, 3})
+	require.NoError(err)
+	require.NoError(f.Close())
+	rl, err = OpenRecordLog(path, s)
+	require.NoError(err)
+	checkRecords(rl)
+	dataBytesRestored, err := os.ReadFile(path)
+	require.NoError(err)
+	require.Equal(dataBytes, dataBytesRestored)
+
+	id, err = rl.AppendBytes(orderBytes)
+	require.NoError(err)
+	require.Equal(4, id)
+	expectedNames = append(expectedNames, "order")
+	checkRecords(rl)
+	require.NoError(rl.RebuildIndex())
+	checkRecords(rl)
+	require.NoError(rl.Close())
+	reopen()
+
+	require.Zero(GetObjectsInUse())
+}
@@ -0,0 +1,273 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/untillpro/gojay"
+)
+
+// RedactionAction defines how the value of a sensitive field is rendered
+type RedactionAction int
+
+const (
+	// RedactionRedact replaces the value by RedactionPolicy.Placeholder
+	RedactionRedact RedactionAction = iota
+	// RedactionOmit skips the field
+	RedactionOmit
+	// RedactionHash replaces the value by hex HMAC-SHA-256 of its text keyed by RedactionPolicy.HashKey. Acts as RedactionRedact if
+	// HashKey is empty: plain hash of a low-entropy value (phone, card number, PIN) is reversed by brute force
+	RedactionHash
+	// RedactionMask replaces all characters of the value text but the last RedactionPolicy.MaskKeep ones by `*`
+	RedactionMask
+	// RedactionShow renders the value as is
+	RedactionShow
+)
+
+// DefaultRedactionPlaceholder replaces redacted values if RedactionPolicy.Placeholder is empty
+const DefaultRedactionPlaceholder = "[REDACTED]"
+
+// RedactionPolicy defines rendering of fields which have Field.Sensitivity label. Fields without a label are rendered as is
+// Hash and mask are applied to the text of a scalar or a string, to each element of an array of scalars or strings, to base64 of a byte array
+// Nested objects and arrays of nested objects are redacted wholly by RedactionRedact, RedactionHash and RedactionMask
+// Zero value redacts values of all sensitive fields
+type RedactionPolicy struct {
+	Actions     map[string]RedactionAction // by sensitivity label
+	Default     RedactionAction            // for labels which are absent in Actions
+	Placeholder string
+	HashKey     []byte
+	MaskKeep    int
+}
+
+// DefaultRedactionPolicy is used by Buffer.Redacted(nil)
+var DefaultRedactionPolicy = &RedactionPolicy{}
+
+// RedactedBuffer renders the Buffer according to RedactionPolicy. Rendering of the Buffer itself is not changed so the same Buffer could be
+// rendered for storage by Buffer.ToJSON() and for logs by Buffer.Redacted(policy).ToJSON()
+// The policy of the view is applied instead of the one set by Buffer.SetRedactionPolicy()
+type RedactedBuffer struct {
+	buffer *Buffer
+	policy *RedactionPolicy
+}
+
+// Redacted returns view of the Buffer which renders sensitive fields according to the policy. nil -> DefaultRedactionPolicy
+// The view is valid until the Buffer is released
+func (b *Buffer) Redacted(policy *RedactionPolicy) *RedactedBuffer {
+	if policy == nil {
+		policy = DefaultRedactionPolicy
+	}
+	return &RedactedBuffer{buffer: b, policy: policy}
+}
+
+// SetRedactionPolicy makes MarshalJSONObject(), ToJSON(), ToJSONMap() and IterateFields() of the Buffer render sensitive fields according to
+// the policy, e.g. for a Buffer which is passed to a logger. nil -> values are rendered as is. Nested objects are rendered by the policy of the Buffer
+// Reset on Release(). ToBytes() and comparison, diff and patch functions are not affected
+func (b *Buffer) SetRedactionPolicy(policy *RedactionPolicy) {
+	b.redaction = policy
+}
+
+// MarshalJSONObject is an analogue of Buffer.MarshalJSONObject() which considers the policy. Complies to gojay.MarshalerJSONObject interface
+func (rb *RedactedBuffer) MarshalJSONObject(enc *gojay.Encoder) {
+	rb.buffer.marshalJSONObject(enc, rb.policy)
+}
+
+// IsNil complies to gojay.MarshalerJSONObject interface
+func (rb *RedactedBuffer) IsNil() bool {
+	return rb.buffer.IsNil()
+}
+
+// ToJSON is an analogue of Buffer.ToJSON() which considers the policy
+func (rb *RedactedBuffer) ToJSON() []byte {
+	return rb.buffer.toJSON(rb.policy)
+}
+
+// ToJSONMap is an analogue of Buffer.ToJSONMap() which considers the policy. Redacted values are strings or []string
+func (rb *RedactedBuffer) ToJSONMap() map[string]interface{} {
+	return rb.buffer.toJSONMap(rb.policy)
+}
+
+// IterateFields is an analogue of Buffer.IterateFields() which considers the policy. Values are provided as ToJSONMap() returns them,
+// i.e. nested objects are map[string]interface{}, arrays of nested objects are []interface{}. Pending modifications are considered
+func (rb *RedactedBuffer) IterateFields(names []string, callback func(name string, value interface{}) bool) {
+	m := rb.ToJSONMap()
+	if len(names) == 0 {
+		for _, f := range rb.buffer.Scheme.Fields {
+			if value, ok := m[f.Name]; ok {
+				if !callback(f.Name, value) {
+					return
+				}
+			}
+		}
+		return
+	}
+	for _, name := range names {
+		if value, ok := m[name]; ok {
+			if !callback(name, value) {
+				return
+			}
+		}
+	}
+}
+
+func (rb *RedactedBuffer) String() string {
+	return string(rb.ToJSON())
+}
+
+// action returns the action for the field. nil policy -> RedactionShow
+func (p *RedactionPolicy) action(f *Field) RedactionAction {
+	if p == nil || len(f.Sensitivity) == 0 {
+		return RedactionShow
+	}
+	if action, ok := p.Actions[f.Sensitivity]; ok {
+		return action
+	}
+	return p.Default
+}
+
+// redact returns string or []string which replaces the value
+func (p *RedactionPolicy) redact(f *Field, action RedactionAction, value interface{}) interface{} {
+	if action == RedactionRedact || f.Ft == FieldTypeObject {
+		return p.placeholder()
+	}
+	if !f.IsArray || f.Ft == FieldTypeByte {
+		return p.redactText(action, valueText(value))
+	}
+	res := []string{}
+	switch arr := value.(type) {
+	case IInt16Array:
+		res = appendRedactedElems(res, p, action, arr)
+	case IInt32Array:
+		res = appendRedactedElems(res, p, action, arr)
+	case IInt64Array:
+		res = appendRedactedElems(res, p, action, arr)
+	case IFloat32Array:
+		res = appendRedactedElems(res, p, action, arr)
+	case IFloat64Array:
+		res = appendRedactedElems(res, p, action, arr)
+	case IStringArray:
+		res = appendRedactedElems(res, p, action, arr)
+	case IBoolArray:
+		res = appendRedactedElems(res, p, action, arr)
+	default:
+		// []T
+		v := reflect.ValueOf(value)
+		if v.Kind() == reflect.Slice {
+			for i := 0; i < v.Len(); i++ {
+				res = append(res, p.redactText(action, fmt.Sprint(v.Index(i).Interface())))
+			}
+		}
+	}
+	return res
+}
+
+func (p *RedactionPolicy) placeholder() string {
+	if len(p.Placeholder) == 0 {
+		return DefaultRedactionPlaceholder
+	}
+	return p.Placeholder
+}
+
+func (p *RedactionPolicy) redactText(action RedactionAction, text string) string {
+	switch action {
+	case RedactionHash:
+		if len(p.HashKey) == 0 {
+			break
+		}
+		mac := hmac.New(sha256.New, p.HashKey)
+		mac.Write([]byte(text))
+		return hex.EncodeToString(mac.Sum(nil))
+	case RedactionMask:
+		// short value is masked wholly
+		keepFrom := utf8.RuneCountInString(text) - p.MaskKeep
+		if p.MaskKeep <= 0 || keepFrom <= 0 {
+			return strings.Repeat("*", utf8.RuneCountInString(text))
+		}
+		sb := strings.Builder{}
+		for i, r := range []rune(text) {
+			if i < keepFrom {
+				sb.WriteByte('*')
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+		return sb.String()
+	}
+	return p.placeholder()
+}
+
+func appendRedactedElems[T any](res []string, p *RedactionPolicy, action RedactionAction, arr interface {
+	Len() int
+	At(idx int) T
+}) []string {
+	for i := 0; i < arr.Len(); i++ {
+		res = append(res, p.redactText(action, fmt.Sprint(arr.At(i))))
+	}
+	return res
+}
+
+// valueText returns text of a scalar, a string or base64 of a byte array
+func valueText(value interface{}) string {
+	switch typed := value.(type) {
+	case string:
+		return typed
+	case []byte:
+		return base64.StdEncoding.EncodeToString(typed)
+	case IByteArray:
+		return base64.StdEncoding.EncodeToString(typed.Bytes())
+	}
+	return fmt.Sprint(value)
+}
+
+// isEmptyJSONValue returns true if the value is not rendered to JSON: empty array or empty nested object
+func isEmptyJSONValue(f *Field, value interface{}) bool {
+	if f.IsArray {
+		return getArrayLen(value) == 0
+	}
+	if nested, ok := value.(*Buffer); ok {
+		return nested.IsNil()
+	}
+	return false
+}
+
+func encodeRedacted(enc *gojay.Encoder, key string, redacted interface{}) {
+	switch typed := redacted.(type) {
+	case string:
+		enc.StringKey(key, typed)
+	case []string:
+		enc.ArrayKey(key, gojay.EncodeArrayFunc(func(enc *gojay.Encoder) {
+			for _, s := range typed {
+				enc.String(s)
+			}
+		}))
+	}
+}
+
+// rawBuffer encodes the Buffer by gojay as is ignoring the policy set by Buffer.SetRedactionPolicy()
+type rawBuffer struct {
+	*Buffer
+}
+
+func (rb rawBuffer) MarshalJSONObject(enc *gojay.Encoder) {
+	rb.marshalJSONObject(enc, nil)
+}
+
+// jsonObject returns object to encode the Buffer by gojay considering the policy. nil -> as is
+func jsonObject(b *Buffer, policy *RedactionPolicy) gojay.MarshalerJSONObject {
+	if policy == nil {
+		return rawBuffer{b}
+	}
+	return &RedactedBuffer{buffer: b, policy: policy}
+}
@@ -0,0 +1,200 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/untillpro/gojay"
+	"gopkg.in/yaml.v2"
+)
+
+var billSchemeYaml = `
+name: string
+CardNo@card: string
+phones..@pii: string
+pin@secret: int32
+photo..@pii: byte
+customer@pii:
+  name: string
+lines..:
+  qty: int32
+  note@pii: string
+`
+
+func getBill(t *testing.T, s *Scheme) *Buffer {
+	b := NewBuffer(s)
+	require.NoError(t, b.ApplyMap(map[string]interface{}{
+		"name":     "bill",
+		"cardNo":   "4111111111111111",
+		"phones":   []interface{}{"+100", "+200"},
+		"pin":      float64(1234),
+		"photo":    []byte{1, 2, 3},
+		"customer": map[string]interface{}{"name": "John"},
+		"lines": []interface{}{
+			map[string]interface{}{"qty": float64(1), "note": "call me"},
+			map[string]interface{}{"qty": float64(2)},
+		},
+	}))
+	bytes, err := b.ToBytes()
+	require.NoError(t, err)
+	b.Release()
+	return ReadBuffer(copyBytes(bytes), s)
+}
+
+func TestSensitivityYaml(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(billSchemeYaml)
+	require.NoError(err)
+	require.Equal("card", s.Field("cardNo").Sensitivity)
+	require.True(s.Field("cardNo").IsMandatory)
+	require.Equal("pii", s.Field("phones").Sensitivity)
+	require.True(s.Field("phones").IsArray)
+	require.Equal("pii", s.Field("customer").Sensitivity)
+	require.Equal("pii", s.Field("lines").FieldScheme.Field("note").Sensitivity)
+	require.Empty(s.Field("name").Sensitivity)
+	require.Empty(s.Field("lines").Sensitivity)
+
+	bytes, err := yaml.Marshal(s)
+	require.NoError(err)
+	s2, err := YamlToScheme(string(bytes))
+	require.NoError(err)
+	bytes2, err := yaml.Marshal(s2)
+	require.NoError(err)
+	require.Equal(bytes, bytes2)
+	require.Equal("card", s2.Field("cardNo").Sensitivity)
+	require.Equal("pii", s2.Field("lines").FieldScheme.Field("note").Sensitivity)
+}
+
+func TestRedaction(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(billSchemeYaml)
+	require.NoError(err)
+	b := getBill(t, s)
+
+	// rendering for storage is not affected
+	require.JSONEq(`{"name":"bill","cardNo":"4111111111111111","phones":["+100","+200"],"pin":1234,"photo":"AQID",
+		"customer":{"name":"John"},"lines":[{"qty":1,"note":"call me"},{"qty":2}]}`, string(b.ToJSON()))
+
+	// zero policy redacts all sensitive fields
+	expected := `{"name":"bill","cardNo":"[REDACTED]","phones":"[REDACTED]","pin":"[REDACTED]","photo":"[REDACTED]",
+		"customer":"[REDACTED]","lines":[{"qty":1,"note":"[REDACTED]"},{"qty":2}]}`
+	require.JSONEq(expected, string(b.Redacted(&RedactionPolicy{}).ToJSON()))
+	require.JSONEq(expected, string(b.Redacted(nil).ToJSON()))
+
+	// policy of the Buffer
+	b.SetRedactionPolicy(&RedactionPolicy{})
+	require.JSONEq(expected, string(b.ToJSON()))
+	marshaled, err := gojay.MarshalJSONObject(b)
+	require.NoError(err)
+	require.JSONEq(expected, string(marshaled))
+	require.Equal(b.Redacted(nil).ToJSONMap(), b.ToJSONMap())
+	iterated := map[string]interface{}{}
+	b.IterateFields(nil, func(name string, value interface{}) bool {
+		iterated[name] = value
+		return true
+	})
+	require.Equal(b.Redacted(nil).ToJSONMap(), iterated)
+	require.JSONEq(string(b.toJSON(nil)), string(b.Redacted(&RedactionPolicy{Default: RedactionShow}).ToJSON())) // policy of the view wins
+	empty := NewBuffer(s)
+	patch, err := MergePatch(empty, b) // not affected
+	require.NoError(err)
+	require.Contains(string(patch), "4111111111111111")
+	empty.Release()
+	b.SetRedactionPolicy(nil)
+	require.Contains(string(b.ToJSON()), "4111111111111111")
+
+	policy := &RedactionPolicy{
+		Actions: map[string]RedactionAction{
+			"card":   RedactionMask,
+			"pii":    RedactionHash,
+			"secret": RedactionOmit,
+		},
+		MaskKeep: 4,
+		HashKey:  []byte("key"),
+	}
+	hash := func(text string) string {
+		mac := hmac.New(sha256.New, []byte("key"))
+		mac.Write([]byte(text))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	expectedMap := map[string]interface{}{
+		"name":     "bill",
+		"cardNo":   "************1111",
+		"phones":   []string{hash("+100"), hash("+200")},
+		"photo":    hash(base64.StdEncoding.EncodeToString([]byte{1, 2, 3})),
+		"customer": DefaultRedactionPlaceholder,
+		"lines": []interface{}{
+			map[string]interface{}{"qty": int32(1), "note": hash("call me")},
+			map[string]interface{}{"qty": int32(2)},
+		},
+	}
+	require.Equal(expectedMap, b.Redacted(policy).ToJSONMap())
+	expectedJSON, err := json.Marshal(expectedMap)
+	require.NoError(err)
+	require.JSONEq(string(expectedJSON), string(b.Redacted(policy).ToJSON()))
+
+	// pending modifications are considered
+	b.Set("cardNo", "5500000000000004")
+	b.Set("phones", []string{"+300"})
+	b.Set("pin", nil)
+	redacted := b.Redacted(policy).ToJSONMap()
+	require.Equal("************0004", redacted["cardNo"])
+	require.Equal([]string{hash("+300")}, redacted["phones"])
+	require.NotContains(string(b.Redacted(policy).ToJSON()), "5500")
+
+	iterated = map[string]interface{}{}
+	b.Redacted(policy).IterateFields(nil, func(name string, value interface{}) bool {
+		iterated[name] = value
+		return true
+	})
+	require.Equal(redacted, iterated)
+	names := []string{}
+	b.Redacted(policy).IterateFields([]string{"pin", "cardNo", "lines", "unknown"}, func(name string, value interface{}) bool {
+		names = append(names, name)
+		return name != "cardNo"
+	})
+	require.Equal([]string{"cardNo"}, names)
+
+	// default action, placeholder, show
+	policy = &RedactionPolicy{
+		Actions:     map[string]RedactionAction{"card": RedactionShow},
+		Default:     RedactionMask,
+		MaskKeep:    3,
+		Placeholder: "***",
+	}
+	redacted = b.Redacted(policy).ToJSONMap()
+	require.Equal("5500000000000004", redacted["cardNo"])
+	require.Equal([]string{"*300"}, redacted["phones"])
+	require.Equal("***", redacted["customer"])
+	require.Equal("*QID", redacted["photo"])
+	require.Equal("**** me", redacted["lines"].([]interface{})[0].(map[string]interface{})["note"])
+	policy.MaskKeep = 16
+	require.Equal([]string{"****"}, b.Redacted(policy).ToJSONMap()["phones"]) // value is not longer than MaskKeep -> masked wholly
+
+	// no hash without key, empty arrays are not rendered
+	b.Set("phones", []string{})
+	b.Set("lines", nil)
+	b.Set("pin", int32(7))
+	hashPolicy := &RedactionPolicy{Default: RedactionHash}
+	redacted = b.Redacted(hashPolicy).ToJSONMap()
+	require.Equal(DefaultRedactionPlaceholder, redacted["pin"])
+	pinHash := sha256.Sum256([]byte("7"))
+	require.NotContains(string(b.Redacted(hashPolicy).ToJSON()), hex.EncodeToString(pinHash[:]))
+	require.NotContains(redacted, "phones")
+	require.NotContains(string(b.Redacted(hashPolicy).ToJSON()), "phones")
+
+	b.Release()
+	require.Zero(GetObjectsInUse())
+}
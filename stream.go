@@ -0,0 +1,194 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// Stream is a sequence of FlatBuffers size-prefixed records: [uint32 little-endian size][size bytes of the buffer]
+// Empty Buffer is written as the record of zero size
+
+// DefaultMaxRecordSize is the default StreamReader.MaxRecordSize
+const DefaultMaxRecordSize = 64 * 1024 * 1024
+
+var (
+	// ErrRecordTooLarge is returned if the record size exceeds the limit
+	ErrRecordTooLarge = errors.New("record is too large")
+	// ErrTruncatedRecord is returned if the stream ends in the middle of a record
+	ErrTruncatedRecord = errors.New("truncated record")
+)
+
+// StreamWriter writes Buffers to io.Writer as size-prefixed records. Each record is written by a single Write() call
+// Not goroutine-safe
+type StreamWriter struct {
+	w       io.Writer
+	builder *flatbuffers.Builder
+	record  []byte
+}
+
+// NewStreamWriter creates StreamWriter which writes to `w`
+func NewStreamWriter(w io.Writer) *StreamWriter {
+	return &StreamWriter{
+		w:       w,
+		builder: flatbuffers.NewBuilder(0),
+	}
+}
+
+// Write writes bytes of the Buffer as ToBytes() does. Error is returned if the Buffer could not be encoded or on write failure
+func (sw *StreamWriter) Write(b *Buffer) error {
+	sw.builder.Reset()
+	uOffsetT, err := b.encodeBuffer(sw.builder)
+	if err != nil {
+		return err
+	}
+	if uOffsetT == 0 {
+		return sw.WriteBytes(nil)
+	}
+	sw.builder.FinishSizePrefixed(uOffsetT)
+	_, err = sw.w.Write(sw.builder.FinishedBytes())
+	return err
+}
+
+// WriteBytes writes bytes got from ToBytes()
+func (sw *StreamWriter) WriteBytes(bytes []byte) error {
+	if uint64(len(bytes)) > math.MaxUint32 {
+		return fmt.Errorf("%w: %d bytes", ErrRecordTooLarge, len(bytes))
+	}
+	sw.record = binary.LittleEndian.AppendUint32(sw.record[:0], uint32(len(bytes)))
+	sw.record = append(sw.record, bytes...)
+	_, err := sw.w.Write(sw.record)
+	return err
+}
+
+// StreamReader reads size-prefixed records from io.Reader as Buffers of the Scheme
+// Usage:
+//
+//	sr := NewStreamReader(r, scheme)
+//	defer sr.Release()
+//	for sr.Next() {
+//		sr.Buffer().Get("name")
+//	}
+//	if err := sr.Err(); err != nil {
+//		...
+//	}
+//
+// Not goroutine-safe
+type StreamReader struct {
+	// MaxRecordSize is the max size of a record, bigger record -> ErrRecordTooLarge. DefaultMaxRecordSize by default
+	MaxRecordSize int
+
+	r       io.Reader
+	scheme  *Scheme
+	opts    readOptions
+	buffer  *Buffer
+	bytes   []byte
+	prefix  [flatbuffers.SizeUint32]byte
+	offset  int64
+	lastLen int64
+	err     error
+}
+
+// NewStreamReader creates StreamReader which reads from `r`. Each record is checked according to `opts`
+func NewStreamReader(r io.Reader, scheme *Scheme, opts ...ReadOption) *StreamReader {
+	return &StreamReader{
+		MaxRecordSize: DefaultMaxRecordSize,
+		r:             r,
+		scheme:        scheme,
+		opts:          newReadOptions(opts),
+	}
+}
+
+// Next reads the next record. Returns false if the stream is over or on error, see Err()
+func (sr *StreamReader) Next() bool {
+	if sr.err != nil {
+		return false
+	}
+	sr.offset += sr.lastLen
+	sr.lastLen = 0
+	n, err := io.ReadFull(sr.r, sr.prefix[:])
+	if err != nil {
+		if err == io.EOF {
+			sr.err = io.EOF
+		} else {
+			sr.err = sr.readError(err, n, len(sr.prefix))
+		}
+		return false
+	}
+	size := int64(binary.LittleEndian.Uint32(sr.prefix[:]))
+	if size > int64(sr.MaxRecordSize) {
+		sr.err = fmt.Errorf("%w: %d bytes at offset %d, max %d", ErrRecordTooLarge, size, sr.offset, sr.MaxRecordSize)
+		return false
+	}
+	if int64(cap(sr.bytes)) < size {
+		sr.bytes = make([]byte, size)
+	}
+	sr.bytes = sr.bytes[:size]
+	if n, err = io.ReadFull(sr.r, sr.bytes); err != nil {
+		sr.err = sr.readError(err, len(sr.prefix)+n, len(sr.prefix)+int(size))
+		return false
+	}
+	if sr.err = sr.opts.checkBytes(sr.bytes, sr.scheme); sr.err != nil {
+		return false
+	}
+	if sr.buffer == nil {
+		sr.buffer = NewBuffer(sr.scheme)
+	}
+	sr.buffer.Reset(sr.bytes)
+	if sr.err = sr.opts.checkBuffer(sr.buffer); sr.err != nil {
+		return false
+	}
+	sr.lastLen = int64(len(sr.prefix)) + size
+	return true
+}
+
+func (sr *StreamReader) readError(err error, read int, expected int) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("%w: %d of %d bytes at offset %d", ErrTruncatedRecord, read, expected, sr.offset)
+	}
+	return err
+}
+
+// Buffer returns the Buffer of the current record. The Buffer and its bytes are reused by the next Next() call, so strings and byte arrays got
+// from the Buffer must be copied if they are needed after that
+func (sr *StreamReader) Buffer() *Buffer {
+	return sr.buffer
+}
+
+// Bytes returns bytes of the current record. Bytes are reused by the next Next() call
+func (sr *StreamReader) Bytes() []byte {
+	return sr.bytes
+}
+
+// Offset returns the position in the stream where the current record starts. After the stream is over it is the position after the last whole record,
+// e.g. the size a file with a truncated tail record should be cut to
+func (sr *StreamReader) Offset() int64 {
+	return sr.offset
+}
+
+// Err returns the error which stopped Next(). nil if the stream is just over
+func (sr *StreamReader) Err() error {
+	if sr.err == io.EOF {
+		return nil
+	}
+	return sr.err
+}
+
+// Release releases the reused Buffer
+func (sr *StreamReader) Release() {
+	if sr.buffer != nil {
+		sr.buffer.Release()
+		sr.buffer = nil
+	}
+}
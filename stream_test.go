@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStream(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(orderSchemeYaml)
+	require.NoError(err)
+	orderBytes := getOrderBytes(t, s)
+
+	stream := bytes.NewBuffer(nil)
+	sw := NewStreamWriter(stream)
+	b := ReadBuffer(orderBytes, s)
+	require.NoError(sw.Write(b))
+	b.Set("name", "modified")
+	require.NoError(sw.Write(b))
+	b.Release()
+	b = NewBuffer(s)
+	require.NoError(sw.Write(b)) // empty
+	b.Release()
+	require.NoError(sw.WriteBytes(orderBytes))
+
+	// FlatBuffers size-prefixed buffer
+	streamBytes := copyBytes(stream.Bytes())
+	size := flatbuffers.GetSizePrefix(streamBytes, 0)
+	b = ReadBuffer(streamBytes[flatbuffers.SizeUint32:flatbuffers.SizeUint32+size], s)
+	require.Equal("order", b.Get("name"))
+	b.Release()
+
+	recordEnds := []int64{}
+	sr := NewStreamReader(bytes.NewReader(streamBytes), s)
+	expectedNames := []interface{}{"order", "modified", nil, "order"}
+	for sr.Next() {
+		// strings refer to the reused bytes
+		require.Equal(expectedNames[len(recordEnds)], sr.Buffer().Get("name"))
+		recordEnds = append(recordEnds, sr.Offset()+int64(flatbuffers.SizeUint32+len(sr.Bytes())))
+	}
+	require.NoError(sr.Err())
+	require.Len(recordEnds, len(expectedNames))
+	require.Equal(int64(len(streamBytes)), sr.Offset())
+	sr.Release()
+
+	// truncated tail
+	for l := 0; l < len(streamBytes); l++ {
+		sr := NewStreamReader(bytes.NewReader(streamBytes[:l]), s)
+		count := 0
+		for sr.Next() {
+			count++
+		}
+		lastEnd, expectedCount := int64(0), 0
+		for _, end := range recordEnds {
+			if end <= int64(l) {
+				lastEnd = end
+				expectedCount++
+			}
+		}
+		require.Equal(expectedCount, count, l)
+		require.Equal(lastEnd, sr.Offset(), l)
+		if lastEnd == int64(l) {
+			require.NoError(sr.Err(), l)
+		} else {
+			require.ErrorIs(sr.Err(), ErrTruncatedRecord, l)
+		}
+		sr.Release()
+	}
+
+	// max record size
+	sr = NewStreamReader(bytes.NewReader(streamBytes), s)
+	sr.MaxRecordSize = len(orderBytes) - 1
+	require.False(sr.Next())
+	require.ErrorIs(sr.Err(), ErrRecordTooLarge)
+	sr.Release()
+
+	// checks
+	corrupted := copyBytes(streamBytes)
+	flatbuffers.WriteUint32(corrupted[flatbuffers.SizeUint32:], 1000)
+	sr = NewStreamReader(bytes.NewReader(corrupted), s, WithVerification(DefaultVerifyOptions))
+	require.False(sr.Next())
+	require.ErrorIs(sr.Err(), ErrInvalidBuffer)
+	sr.Release()
+	sMandatory, err := YamlToScheme(`
+Name: string
+`)
+	require.NoError(err)
+	sr = NewStreamReader(bytes.NewReader(streamBytes), sMandatory, WithMandatoryCheck())
+	require.True(sr.Next())
+	require.True(sr.Next())
+	require.False(sr.Next())
+	require.ErrorIs(sr.Err(), ErrMandatoryFieldsNotSet)
+	sr.Release()
+
+	// read failure
+	sr = NewStreamReader(&failingReader{data: streamBytes[:10]}, s)
+	require.False(sr.Next())
+	require.ErrorIs(sr.Err(), errTestRead)
+	sr.Release()
+
+	require.Zero(GetObjectsInUse())
+}
+
+var errTestRead = errors.New("test read error")
+
+type failingReader struct {
+	data []byte
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, errTestRead
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
@@ -0,0 +1,212 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"unsafe"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// ErrTransform is returned if FieldTransformer failed to encode or decode a value
+var ErrTransform = errors.New("field value transform failed")
+
+// FieldTransformer transforms values of a string or a byte array field, e.g. encrypts or compresses them
+// Encode is called on write (ToBytes() etc), Decode is called on read (Get(), GetString(), GetByteArray(), ToJSON(), ToJSONMap() etc)
+// Decode error is not returned on read: the value is considered as unset. Use Buffer.CheckTransform() to get the error
+// Stored bytes are not modified on Set() so a value is encoded when the Buffer is encoded, stored value is copied as is if it is not modified
+// `src` must not be modified or retained, the result must not refer to `src`. Must be goroutine-safe
+// Note: Compare(), Equal(), Diff(), Filter, KeyEncoder, ColumnExtractor and Verify() work with stored, i.e. encoded, values
+// MutateAt() returns false for a field which has a transformer, use Set() instead
+type FieldTransformer interface {
+	Encode(src []byte) ([]byte, error)
+	Decode(src []byte) ([]byte, error)
+}
+
+// SetTransformer attaches the transformer to the field. nil -> the transformer is detached
+// Error is returned if the field is not a string or a byte array field
+func (f *Field) SetTransformer(t FieldTransformer) error {
+	if (f.Ft != FieldTypeString || f.IsArray) && (f.Ft != FieldTypeByte || !f.IsArray) {
+		return fmt.Errorf("transformer could be set to a string or a byte array field only, field %s", f.QualifiedName())
+	}
+	f.transformer = t
+	return nil
+}
+
+// Transformer returns the transformer attached to the field, nil if none
+func (f *Field) Transformer() FieldTransformer {
+	return f.transformer
+}
+
+// CheckTransform decodes stored values of all fields which have the transformer including ones of nested objects and array elements
+// Useful to tell an unset value from a value which could not be decoded (e.g. wrong key): getters and ToJSON() consider the latter as unset
+// Returns error which wraps ErrTransform if Decode failed. Modifications are not considered
+func (b *Buffer) CheckTransform() error {
+	return checkTransform(b.Scheme, b.tab, "")
+}
+
+func checkTransform(s *Scheme, tab flatbuffers.Table, prefix string) error {
+	if len(tab.Bytes) == 0 {
+		return nil
+	}
+	for _, f := range s.Fields {
+		uOffsetT := flatbuffers.UOffsetT(tab.Offset(flatbuffers.VOffsetT((f.Order + 2) * 2)))
+		if uOffsetT == 0 {
+			continue
+		}
+		if f.transformer != nil {
+			if _, err := f.transformer.Decode(tab.ByteVector(tab.Pos + uOffsetT)); err != nil {
+				return fmt.Errorf("%w: decode field %s: %w", ErrTransform, prefix+f.Name, err)
+			}
+			continue
+		}
+		if f.Ft != FieldTypeObject {
+			continue
+		}
+		if !f.IsArray {
+			if err := checkTransform(f.FieldScheme, flatbuffers.Table{Bytes: tab.Bytes, Pos: tab.Indirect(tab.Pos + uOffsetT)}, prefix+f.Name+"."); err != nil {
+				return err
+			}
+			continue
+		}
+		// elements are stored in reverse order
+		vector := tab.Vector(uOffsetT)
+		l := tab.VectorLen(uOffsetT)
+		for i := 0; i < l; i++ {
+			elem := flatbuffers.Table{Bytes: tab.Bytes, Pos: tab.Indirect(vector + flatbuffers.UOffsetT(l-1-i)*flatbuffers.SizeUOffsetT)}
+			if err := checkTransform(f.FieldScheme, elem, prefix+f.Name+"["+strconv.Itoa(i)+"]."); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// decodeByUOffsetT returns decoded stored value of the field which has the transformer
+func (b *Buffer) decodeByUOffsetT(f *Field, uOffsetT flatbuffers.UOffsetT) ([]byte, error) {
+	res, err := f.transformer.Decode(b.tab.ByteVector(uOffsetT))
+	if err != nil {
+		return nil, fmt.Errorf("%w: decode field %s: %w", ErrTransform, f.QualifiedName(), err)
+	}
+	return res, nil
+}
+
+// decodeStored returns decoded stored value of the field which has the transformer. Unset -> nil
+func (b *Buffer) decodeStored(f *Field) ([]byte, error) {
+	if uOffsetT := b.getFieldUOffsetTByOrder(f.Order); uOffsetT != 0 {
+		return b.decodeByUOffsetT(f, uOffsetT)
+	}
+	return nil, nil
+}
+
+// getByteArrayByUOffsetT returns stored byte array considering the transformer. Decode error -> nil
+func (b *Buffer) getByteArrayByUOffsetT(f *Field, uOffsetT flatbuffers.UOffsetT) IByteArray {
+	if f.transformer == nil {
+		return getImplIByteArray(b, uOffsetT)
+	}
+	decoded, err := b.decodeByUOffsetT(f, uOffsetT)
+	if err != nil {
+		return nil
+	}
+	return decodedByteArray(decoded)
+}
+
+// getStringByUOffsetT returns stored string considering the transformer. Decode error -> false
+func (b *Buffer) getStringByUOffsetT(f *Field, uOffsetT flatbuffers.UOffsetT) (string, bool) {
+	if f.transformer == nil {
+		return byteSliceToString(b.tab.ByteVector(uOffsetT)), true
+	}
+	decoded, err := b.decodeByUOffsetT(f, uOffsetT)
+	if err != nil {
+		return "", false
+	}
+	return byteSliceToString(decoded), true
+}
+
+// decodedByteArray is IByteArray of a decoded value
+type decodedByteArray []byte
+
+func (a decodedByteArray) Bytes() []byte {
+	return a
+}
+
+// createTransformed encodes the value by the transformer of the field if any and writes it as a string or a byte vector. Empty result -> 0
+func createTransformed(bl *flatbuffers.Builder, f *Field, value []byte) (flatbuffers.UOffsetT, error) {
+	if f.transformer != nil && len(value) > 0 {
+		var err error
+		if value, err = f.transformer.Encode(value); err != nil {
+			return 0, fmt.Errorf("%w: encode field %s: %w", ErrTransform, f.QualifiedName(), err)
+		}
+	}
+	switch {
+	case len(value) == 0:
+		return 0, nil
+	case f.IsArray:
+		return bl.CreateByteVector(value), nil
+	}
+	return bl.CreateByteString(value), nil
+}
+
+// encodeTransformedString encodes the modified value of the string field which has the transformer
+func encodeTransformedString(bl *flatbuffers.Builder, f *Field, m *fieldToBytes) (flatbuffers.UOffsetT, error) {
+	if m.typedFt == FieldTypeString && !m.typedIsArray {
+		return createTransformed(bl, f, unsafe.Slice((*byte)(m.typedArr), m.typedArrLen))
+	}
+	switch value := m.getValue().(type) {
+	case string:
+		return createTransformed(bl, f, []byte(value))
+	case []byte:
+		return createTransformed(bl, f, value)
+	default:
+		return 0, fmt.Errorf("string required but %#v provided for field %s", value, f.QualifiedName())
+	}
+}
+
+// encodeTransformedByteArray encodes the modified value of the byte array field which has the transformer
+func (b *Buffer) encodeTransformedByteArray(bl *flatbuffers.Builder, f *Field, m *fieldToBytes) (flatbuffers.UOffsetT, error) {
+	var plain []byte
+	switch {
+	case len(m.arrayOps) > 0:
+		if _, err := b.decodeStored(f); err != nil {
+			return 0, err
+		}
+		arr, err := b.getArrayWithOps(f, m)
+		if err != nil {
+			return 0, err
+		}
+		plain, _ = arr.([]byte)
+	case m.typedFt == f.Ft && m.typedIsArray:
+		plain = unsafe.Slice((*byte)(m.typedArr), m.typedArrLen)
+	case m.isNil():
+		return 0, nil
+	default:
+		switch value := m.getValue().(type) {
+		case []byte:
+			plain = value
+		case string:
+			var err error
+			if plain, err = base64.StdEncoding.DecodeString(value); err != nil {
+				return 0, fmt.Errorf("[]byte or base64-encoded string required but %#v provided for field %s", value, f.QualifiedName())
+			}
+		default:
+			return 0, fmt.Errorf("[]byte or base64-encoded string required but %#v provided for field %s", value, f.QualifiedName())
+		}
+		if m.isAppend && len(plain) > 0 {
+			stored, err := b.decodeStored(f)
+			if err != nil {
+				return 0, err
+			}
+			plain = append(append([]byte{}, stored...), plain...)
+		}
+	}
+	return createTransformed(bl, f, plain)
+}
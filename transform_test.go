@@ -0,0 +1,276 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// aesTransformer encrypts values by AES-GCM with a random nonce, so the same value is encrypted differently each time
+type aesTransformer struct {
+	gcm cipher.AEAD
+}
+
+func newAESTransformer(t *testing.T, key string) *aesTransformer {
+	block, err := aes.NewCipher([]byte(key))
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	return &aesTransformer{gcm: gcm}
+}
+
+func (at *aesTransformer) Encode(src []byte) ([]byte, error) {
+	nonce := make([]byte, at.gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return at.gcm.Seal(nonce, nonce, src, nil), nil
+}
+
+func (at *aesTransformer) Decode(src []byte) ([]byte, error) {
+	if len(src) < at.gcm.NonceSize() {
+		return nil, errors.New("too short")
+	}
+	return at.gcm.Open(nil, src[:at.gcm.NonceSize()], src[at.gcm.NonceSize():], nil)
+}
+
+type failingTransformer struct{}
+
+func (failingTransformer) Encode(src []byte) ([]byte, error) {
+	return nil, errors.New("encode failed")
+}
+
+func (failingTransformer) Decode(src []byte) ([]byte, error) {
+	return nil, errors.New("decode failed")
+}
+
+var transformSchemeYaml = `
+name: string
+cardNo: string
+photo..: byte
+tags..: string
+qty: int32
+`
+
+func TestFieldTransformer(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(transformSchemeYaml)
+	require.NoError(err)
+	transformer := newAESTransformer(t, "0123456789abcdef")
+	require.NoError(s.Field("cardNo").SetTransformer(transformer))
+	require.NoError(s.Field("photo").SetTransformer(transformer))
+	require.Equal(transformer, s.Field("cardNo").Transformer())
+	require.Nil(s.Field("name").Transformer())
+
+	check := func(bs []byte, cardNo string, photo []byte) {
+		t.Helper()
+		require.False(bytes.Contains(bs, []byte(cardNo)), "value is not encoded")
+		b := ReadBuffer(bs, s)
+		defer b.Release()
+		require.Equal(cardNo, b.Get("cardNo"))
+		str, ok := b.GetString("cardNo")
+		require.True(ok)
+		require.Equal(cardNo, str)
+		str, ok = b.GetStringF(s.Field("cardNo"))
+		require.True(ok)
+		require.Equal(cardNo, str)
+		require.Equal(photo, b.Get("photo"))
+		require.Equal(photo, b.GetByteArray("photo").Bytes())
+		require.Equal(photo, b.GetByteArrayF(s.Field("photo")).Bytes())
+		require.Equal(cardNo, b.ToJSONMap()["cardNo"])
+		require.Equal(photo, b.ToJSONMap()["photo"])
+		require.Contains(string(b.ToJSON()), `"cardNo":"`+cardNo+`"`)
+	}
+
+	// Set
+	b := NewBuffer(s)
+	b.Set("name", "bill")
+	b.Set("cardNo", "4111111111111111")
+	b.Set("photo", []byte("photo-bytes"))
+	b.Set("tags", []string{"a"})
+	require.Equal(`{"name":"bill","cardNo":"4111111111111111","photo":"cGhvdG8tYnl0ZXM=","tags":["a"]}`, string(b.ToJSON()))
+	bs, err := b.ToBytes()
+	require.NoError(err)
+	bs = copyBytes(bs)
+	require.False(bytes.Contains(bs, []byte("photo-bytes")))
+	check(bs, "4111111111111111", []byte("photo-bytes"))
+	b.Release()
+
+	// typed setters
+	b = NewBuffer(s)
+	b.SetString("cardNo", "5500000000000004")
+	b.SetByteArray("photo", []byte{1, 2, 3})
+	bsTyped, err := b.ToBytes()
+	require.NoError(err)
+	check(copyBytes(bsTyped), "5500000000000004", []byte{1, 2, 3})
+	b.Release()
+
+	// JSON
+	b = NewBuffer(s)
+	bsJSON, _, err := b.ApplyJSONAndToBytes([]byte(`{"cardNo":"1234","photo":"AQID"}`))
+	require.NoError(err)
+	check(copyBytes(bsJSON), "1234", []byte{1, 2, 3})
+	b.Release()
+
+	// unmodified encoded values are copied as is
+	b = ReadBuffer(bs, s)
+	b.Set("qty", int32(5))
+	bsCopied, err := b.ToBytes()
+	require.NoError(err)
+	bsCopied = copyBytes(bsCopied)
+	stored := ReadBuffer(bs, s)
+	copied := ReadBuffer(bsCopied, s)
+	require.Equal(stored.tab.ByteVector(stored.getFieldUOffsetT("cardNo")), copied.tab.ByteVector(copied.getFieldUOffsetT("cardNo")))
+	require.Equal(stored.tab.ByteVector(stored.getFieldUOffsetT("photo")), copied.tab.ByteVector(copied.getFieldUOffsetT("photo")))
+	stored.Release()
+	copied.Release()
+	b.Release()
+
+	// append and array operations consider decoded values
+	b = ReadBuffer(bs, s)
+	b.Append("photo", []byte("-2"))
+	bsAppended, err := b.ToBytes()
+	require.NoError(err)
+	check(copyBytes(bsAppended), "4111111111111111", []byte("photo-bytes-2"))
+	b.Release()
+	b = ReadBuffer(bs, s)
+	b.RemoveAt("photo", 0)
+	b.InsertAt("photo", 0, byte('P'))
+	bsOps, err := b.ToBytes()
+	require.NoError(err)
+	check(copyBytes(bsOps), "4111111111111111", []byte("Photo-bytes"))
+	b.Release()
+
+	// paths consider decoded values
+	b = ReadBuffer(bs, s)
+	elem, err := b.GetPath("photo[1]")
+	require.NoError(err)
+	require.Equal(byte('h'), elem)
+	_, err = b.GetPath("photo[11]")
+	require.ErrorIs(err, ErrIndexOutOfRange)
+	b.RemoveAt("photo", 0)
+	elem, err = b.GetPath("photo[1]")
+	require.NoError(err)
+	require.Equal(byte('o'), elem)
+	b.Release()
+
+	// encoded bytes could not be mutated in place
+	stored = ReadBuffer(copyBytes(bs), s)
+	require.False(stored.MutateAt("photo", 0, byte('P')))
+	require.Equal([]byte("photo-bytes"), stored.GetByteArray("photo").Bytes())
+	stored.Release()
+
+	// unset
+	b = ReadBuffer(bs, s)
+	b.Set("cardNo", nil)
+	b.Set("photo", []byte{})
+	bsUnset, err := b.ToBytes()
+	require.NoError(err)
+	b.Release()
+	b = ReadBuffer(bsUnset, s)
+	require.Nil(b.Get("cardNo"))
+	require.Nil(b.Get("photo"))
+	require.Equal("bill", b.Get("name"))
+	b.Release()
+
+	require.Zero(GetObjectsInUse())
+}
+
+func TestFieldTransformerErrors(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(transformSchemeYaml)
+	require.NoError(err)
+	require.Error(s.Field("qty").SetTransformer(failingTransformer{}))
+	require.Error(s.Field("tags").SetTransformer(failingTransformer{}))
+
+	require.NoError(s.Field("cardNo").SetTransformer(newAESTransformer(t, "0123456789abcdef")))
+	require.NoError(s.Field("photo").SetTransformer(newAESTransformer(t, "0123456789abcdef")))
+	b := NewBuffer(s)
+	b.Set("cardNo", "4111111111111111")
+	b.Set("photo", []byte{1, 2, 3})
+	bs, err := b.ToBytes()
+	require.NoError(err)
+	bs = copyBytes(bs)
+	b.Release()
+	b = ReadBuffer(bs, s)
+	require.NoError(b.CheckTransform())
+	b.Release()
+
+	// decode failed -> the value is considered as unset on read, error on write
+	wrongKeyScheme, err := YamlToScheme(transformSchemeYaml)
+	require.NoError(err)
+	require.NoError(wrongKeyScheme.Field("cardNo").SetTransformer(newAESTransformer(t, "fedcba9876543210")))
+	require.NoError(wrongKeyScheme.Field("photo").SetTransformer(newAESTransformer(t, "fedcba9876543210")))
+	b = ReadBuffer(bs, wrongKeyScheme)
+	require.Nil(b.Get("cardNo"))
+	_, ok := b.GetString("cardNo")
+	require.False(ok)
+	require.Nil(b.Get("photo"))
+	require.Nil(b.GetByteArray("photo"))
+	require.Equal(`{}`, string(b.ToJSON()))
+	require.Empty(b.ToJSONMap())
+	err = b.CheckTransform()
+	require.ErrorIs(err, ErrTransform)
+	require.Contains(err.Error(), "cardNo")
+	b.Append("photo", []byte{4})
+	_, err = b.ToBytes()
+	require.ErrorIs(err, ErrTransform)
+	b.Release()
+
+	// nested objects and array elements are checked too
+	nestedYaml := `
+lines..:
+  note: string
+`
+	nested, err := YamlToScheme(nestedYaml)
+	require.NoError(err)
+	require.NoError(nested.GetNestedScheme("lines").Field("note").SetTransformer(newAESTransformer(t, "0123456789abcdef")))
+	b = NewBuffer(nested)
+	require.NoError(b.ApplyMap(map[string]interface{}{"lines": []interface{}{
+		map[string]interface{}{"note": "a"},
+		map[string]interface{}{"note": "b"},
+	}}))
+	bs, err = b.ToBytes()
+	require.NoError(err)
+	bs = copyBytes(bs)
+	b.Release()
+	nestedWrongKey, err := YamlToScheme(nestedYaml)
+	require.NoError(err)
+	require.NoError(nestedWrongKey.GetNestedScheme("lines").Field("note").SetTransformer(newAESTransformer(t, "fedcba9876543210")))
+	b = ReadBuffer(bs, nestedWrongKey)
+	err = b.CheckTransform()
+	require.ErrorIs(err, ErrTransform)
+	require.Contains(err.Error(), "lines[0].note")
+	b.Release()
+
+	// encode failed
+	require.NoError(s.Field("cardNo").SetTransformer(failingTransformer{}))
+	b = NewBuffer(s)
+	b.Set("cardNo", "4111111111111111")
+	_, err = b.ToBytes()
+	require.ErrorIs(err, ErrTransform)
+	b.Release()
+
+	// detached transformer -> stored bytes are read as is
+	require.NoError(s.Field("cardNo").SetTransformer(nil))
+	b = NewBuffer(s)
+	b.Set("cardNo", "4111111111111111")
+	bs, err = b.ToBytes()
+	require.NoError(err)
+	require.True(bytes.Contains(bs, []byte("4111111111111111")))
+	b.Release()
+
+	require.Zero(GetObjectsInUse())
+}
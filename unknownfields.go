@@ -0,0 +1,291 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// Fields appended to the Scheme by a newer version are unknown for the Buffer: they are stored in vtable slots beyond the Scheme's last field
+// Unknown fields are written after the known ones, so their values are the lowest part of the table inline data and their strings, arrays and nested
+// objects are located right after the table inline data, before data of known fields:
+//
+//	[soffset][unknown slots][known slots][unknown fields data][known fields data]
+//
+// Types of unknown fields are unknown so they are copied as opaque bytes keeping distances between them: offsets stored in unknown slots and inside
+// the unknown fields data are still valid. Copied bytes keep their alignment modulo maxAlignment relative to the end of the bytes, i.e. 8-byte scalars and
+// 4-byte offsets stay aligned for readers which check alignment. Padding is deterministic so repeated rewrite does not grow the data
+//
+// The layout above is the layout of this library's writer which writes data and slots of fields in order of the Scheme fields. Tables laid out
+// differently (e.g. written by flatc generated code which orders slots by size) are not recognized and their unknown fields are dropped
+
+// maxAlignment is the largest alignment of a FlatBuffers scalar
+const maxAlignment = 8
+
+// copyUnknownFields copies [unknown slots][known slots][unknown fields data]. Stale copies of known slots are not referenced by the vtable
+// Returns true if the object is started, i.e. there are unknown fields. Must be called after data of known fields is written and before the object is started
+func (b *Buffer) copyUnknownFields(bl *flatbuffers.Builder) bool {
+	slotsCount, start, inlineEnd := unknownSlots(b.Scheme, &b.tab)
+	if start == inlineEnd || !isWriterLayout(b.Scheme, b.tab) {
+		return false
+	}
+	end := b.knownDataStart(b.tab.Pos)
+	if end == 0 {
+		end = b.storedDataEnd()
+	}
+	// could be if data of fields overlap, i.e. bytes are malformed but passed Verify()
+	end = max(end, inlineEnd)
+	end = b.sharedVtablesEnd(start, end)
+
+	b.prependStoredBytes(bl, inlineEnd, end)
+	bl.StartObject(slotsCount)
+	for pos := inlineEnd; pos > start; pos-- {
+		bl.PrependByte(b.tab.Bytes[pos-1])
+		vOffsetT := flatbuffers.VOffsetT(pos - 1 - b.tab.Pos)
+		for slot := len(b.Scheme.Fields); slot < slotsCount; slot++ {
+			if b.tab.Offset(flatbuffers.VOffsetT((slot+2)*2)) == vOffsetT {
+				bl.Slot(slot)
+			}
+		}
+	}
+	return true
+}
+
+// copyRawObjectArray copies stored array of nested objects as is if it contains unknown fields at any depth
+// Elements could share vtables with each other, so copying the array at once keeps the result size linear
+// Returns false if there are no unknown fields, i.e. the array should be re-encoded
+func (b *Buffer) copyRawObjectArray(bl *flatbuffers.Builder, arrayUOffsetT flatbuffers.UOffsetT, f *Field) (flatbuffers.UOffsetT, bool) {
+	vector := b.tab.Vector(arrayUOffsetT - b.tab.Pos)
+	l := b.tab.VectorLen(arrayUOffsetT - b.tab.Pos)
+	hasUnknown := false
+	lastElem := flatbuffers.UOffsetT(0)
+	for i := 0; i < l; i++ {
+		elem := flatbuffers.Table{Bytes: b.tab.Bytes, Pos: b.tab.Indirect(vector + flatbuffers.UOffsetT(i)*flatbuffers.SizeUOffsetT)}
+		if hasForeignLayout(f.FieldScheme, elem) {
+			return 0, false // elements are re-encoded one by one, unknown fields of unrecognized tables are dropped
+		}
+		hasUnknown = hasUnknown || hasUnknownFields(f.FieldScheme, elem)
+		lastElem = max(lastElem, elem.Pos)
+	}
+	if !hasUnknown {
+		return 0, false
+	}
+	start := b.tab.Indirect(arrayUOffsetT)
+	end := b.knownDataStart(lastElem)
+	if end == 0 {
+		end = b.storedDataEnd()
+	}
+	end = b.sharedVtablesEnd(start, end)
+	b.prependStoredBytes(bl, start, end)
+	return bl.Offset(), true
+}
+
+// unknownSlots returns range of the table inline data which contains all non-empty unknown slots. start == inlineEnd -> no unknown fields
+func unknownSlots(s *Scheme, tab *flatbuffers.Table) (slotsCount int, start, inlineEnd flatbuffers.UOffsetT) {
+	if len(tab.Bytes) == 0 {
+		return 0, 0, 0
+	}
+	vtable := flatbuffers.UOffsetT(flatbuffers.SOffsetT(tab.Pos) - tab.GetSOffsetT(tab.Pos))
+	slotsCount = (int(tab.GetVOffsetT(vtable)) - 2*flatbuffers.SizeVOffsetT) / flatbuffers.SizeVOffsetT
+	inlineEnd = tab.Pos + flatbuffers.UOffsetT(tab.GetVOffsetT(vtable+flatbuffers.SizeVOffsetT))
+	start = inlineEnd
+	for slot := len(s.Fields); slot < slotsCount; slot++ {
+		if vOffsetT := tab.Offset(flatbuffers.VOffsetT((slot + 2) * 2)); vOffsetT != 0 && tab.Pos+flatbuffers.UOffsetT(vOffsetT) < start {
+			start = tab.Pos + flatbuffers.UOffsetT(vOffsetT)
+		}
+	}
+	return slotsCount, start, inlineEnd
+}
+
+// hasUnknownFields returns true if the stored table or any of its nested objects has unknown fields
+func hasUnknownFields(s *Scheme, tab flatbuffers.Table) bool {
+	return anyStoredTable(s, tab, func(s *Scheme, tab flatbuffers.Table) bool {
+		_, start, inlineEnd := unknownSlots(s, &tab)
+		return start != inlineEnd
+	})
+}
+
+// hasForeignLayout returns true if the stored table or any of its nested objects has unknown fields but is not laid out by this library's writer
+func hasForeignLayout(s *Scheme, tab flatbuffers.Table) bool {
+	return anyStoredTable(s, tab, func(s *Scheme, tab flatbuffers.Table) bool {
+		_, start, inlineEnd := unknownSlots(s, &tab)
+		return start != inlineEnd && !isWriterLayout(s, tab)
+	})
+}
+
+// anyStoredTable returns true if `match` is true for the stored table or any of its nested objects including elements of arrays
+func anyStoredTable(s *Scheme, tab flatbuffers.Table, match func(s *Scheme, tab flatbuffers.Table) bool) bool {
+	if match(s, tab) {
+		return true
+	}
+	for _, f := range s.Fields {
+		if f.Ft != FieldTypeObject {
+			continue
+		}
+		vOffsetT := flatbuffers.UOffsetT(tab.Offset(flatbuffers.VOffsetT((f.Order + 2) * 2)))
+		if vOffsetT == 0 {
+			continue
+		}
+		if !f.IsArray {
+			if anyStoredTable(f.FieldScheme, flatbuffers.Table{Bytes: tab.Bytes, Pos: tab.Indirect(tab.Pos + vOffsetT)}, match) {
+				return true
+			}
+			continue
+		}
+		vector := tab.Vector(vOffsetT)
+		for i := 0; i < tab.VectorLen(vOffsetT); i++ {
+			if anyStoredTable(f.FieldScheme, flatbuffers.Table{Bytes: tab.Bytes, Pos: tab.Indirect(vector + flatbuffers.UOffsetT(i)*flatbuffers.SizeUOffsetT)}, match) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isWriterLayout returns true if the stored table is laid out by this library's writer, i.e. slots and data of fields are written in order of fields:
+// positions of known slots and of unknown slots decrease by field order, known slots are above unknown ones (written by a newer Scheme) or below
+// them (rewritten by an older Scheme, unknown slots are copied before known ones are written), positions of known fields data decrease by field order
+// The last guarantees data of unknown fields, which are written after known ones, is located between the table inline data and known fields data
+func isWriterLayout(s *Scheme, tab flatbuffers.Table) bool {
+	vtable := flatbuffers.UOffsetT(flatbuffers.SOffsetT(tab.Pos) - tab.GetSOffsetT(tab.Pos))
+	slotsCount := (int(tab.GetVOffsetT(vtable)) - 2*flatbuffers.SizeVOffsetT) / flatbuffers.SizeVOffsetT
+	var lastKnown, firstKnown, lastUnknown, firstUnknown, lastData flatbuffers.UOffsetT
+	for slot := 0; slot < slotsCount; slot++ {
+		vOffsetT := tab.Offset(flatbuffers.VOffsetT((slot + 2) * 2))
+		if vOffsetT == 0 {
+			continue
+		}
+		pos := tab.Pos + flatbuffers.UOffsetT(vOffsetT)
+		if slot >= len(s.Fields) {
+			if lastUnknown != 0 && pos >= lastUnknown {
+				return false
+			}
+			if firstUnknown == 0 {
+				firstUnknown = pos
+			}
+			lastUnknown = pos
+			continue
+		}
+		if lastKnown != 0 && pos >= lastKnown {
+			return false
+		}
+		if firstKnown == 0 {
+			firstKnown = pos
+		}
+		lastKnown = pos
+		if f := s.Fields[slot]; f.IsArray || f.Ft == FieldTypeString || f.Ft == FieldTypeObject {
+			data := tab.Indirect(pos)
+			if lastData != 0 && data >= lastData {
+				return false
+			}
+			lastData = data
+		}
+	}
+	return firstKnown == 0 || firstUnknown == 0 || lastKnown > firstUnknown || firstKnown < lastUnknown
+}
+
+// sharedVtablesEnd returns position where vtables used by tables located within [from, to) end, at least `to`
+// Flatbuffers writer shares the vtable of a table with an equal vtable written earlier, i.e. a nested object of an unknown field could refer to a
+// vtable located above the unknown fields data. Any position which looks like a table is considered because types of unknown fields are unknown:
+// a false positive just makes the copied bytes larger, a table is never missed. Tables are 4-aligned relative to the end of the bytes because
+// copied bytes keep alignment. Called for tables recognized by isWriterLayout() only
+func (b *Buffer) sharedVtablesEnd(from, to flatbuffers.UOffsetT) flatbuffers.UOffsetT {
+	res := to
+	bytesLen := flatbuffers.UOffsetT(len(b.tab.Bytes))
+	for pos := from; pos+flatbuffers.SizeSOffsetT <= to; pos++ {
+		if (bytesLen-pos)%flatbuffers.SizeSOffsetT != 0 {
+			continue
+		}
+		vtable := int64(pos) - int64(b.tab.GetSOffsetT(pos))
+		if vtable < int64(to) || vtable+2*flatbuffers.SizeVOffsetT > int64(bytesLen) || (int64(bytesLen)-vtable)%flatbuffers.SizeVOffsetT != 0 {
+			continue
+		}
+		vtablePos := flatbuffers.UOffsetT(vtable)
+		vtableSize := flatbuffers.UOffsetT(b.tab.GetVOffsetT(vtablePos))
+		objectSize := flatbuffers.UOffsetT(b.tab.GetVOffsetT(vtablePos + flatbuffers.SizeVOffsetT))
+		if vtableSize < 2*flatbuffers.SizeVOffsetT || vtableSize%flatbuffers.SizeVOffsetT != 0 || vtablePos+vtableSize > bytesLen ||
+			objectSize < flatbuffers.SizeSOffsetT {
+			continue
+		}
+		isVtable := true
+		for slot := vtablePos + 2*flatbuffers.SizeVOffsetT; slot < vtablePos+vtableSize && isVtable; slot += flatbuffers.SizeVOffsetT {
+			vOffsetT := flatbuffers.UOffsetT(b.tab.GetVOffsetT(slot))
+			isVtable = vOffsetT == 0 || (vOffsetT >= flatbuffers.SizeSOffsetT && vOffsetT < objectSize)
+		}
+		if isVtable && vtablePos+vtableSize > res {
+			res = vtablePos + vtableSize
+		}
+	}
+	return res
+}
+
+// storedDataEnd returns position where data written for the stored table ends, i.e. the nearest data written before the table
+func (b *Buffer) storedDataEnd() flatbuffers.UOffsetT {
+	owner := b.owner
+	if owner == nil || len(owner.tab.Bytes) == 0 || len(owner.tab.Bytes) != len(b.tab.Bytes) || &owner.tab.Bytes[0] != &b.tab.Bytes[0] ||
+		owner.tab.Pos == b.tab.Pos {
+		// the root or the owner is unknown -> up to the end of the buffer
+		return flatbuffers.UOffsetT(len(b.tab.Bytes))
+	}
+	if res := owner.knownDataStart(b.tab.Pos); res != 0 {
+		return res
+	}
+	return owner.storedDataEnd()
+}
+
+// knownDataStart returns the lowest position of stored strings, arrays, nested objects and their vtables which is above `pos`, 0 if none
+func (b *Buffer) knownDataStart(pos flatbuffers.UOffsetT) flatbuffers.UOffsetT {
+	res := flatbuffers.UOffsetT(0)
+	consider := func(candidate flatbuffers.UOffsetT) {
+		if candidate > pos && (res == 0 || candidate < res) {
+			res = candidate
+		}
+	}
+	considerTable := func(table flatbuffers.UOffsetT) {
+		consider(table)
+		// the vtable is written right before the table if it is not shared with a table written earlier
+		if vtable := flatbuffers.UOffsetT(flatbuffers.SOffsetT(table) - b.tab.GetSOffsetT(table)); vtable < table {
+			consider(vtable)
+		}
+	}
+	for _, f := range b.Scheme.Fields {
+		if !f.IsArray && f.Ft != FieldTypeString && f.Ft != FieldTypeObject {
+			continue
+		}
+		uOffsetT := b.getFieldUOffsetTByOrder(f.Order)
+		if uOffsetT == 0 {
+			continue
+		}
+		target := b.tab.Indirect(uOffsetT)
+		switch {
+		case f.IsArray && f.Ft == FieldTypeObject:
+			consider(target)
+			start := b.tab.Vector(uOffsetT - b.tab.Pos)
+			for i := 0; i < b.tab.VectorLen(uOffsetT-b.tab.Pos); i++ {
+				considerTable(b.tab.Indirect(start + flatbuffers.UOffsetT(i)*flatbuffers.SizeUOffsetT))
+			}
+		case f.Ft == FieldTypeObject:
+			considerTable(target)
+		default:
+			consider(target)
+		}
+	}
+	return res
+}
+
+// prependStoredBytes prepends stored bytes [from, to) keeping their alignment: distance from each copied byte to the end of the built bytes
+// equals to the distance from the source byte to the end of the stored bytes modulo maxAlignment
+func (b *Buffer) prependStoredBytes(bl *flatbuffers.Builder, from, to flatbuffers.UOffsetT) {
+	// minimal padding: stored bytes which are rewritten again are already aligned and get no padding
+	endAlignment := int((flatbuffers.UOffsetT(len(b.tab.Bytes)) - to) % maxAlignment)
+	bl.Prep(maxAlignment, (maxAlignment-endAlignment)%maxAlignment)
+	bl.Prep(1, int(to-from))
+	for pos := to; pos > from; pos-- {
+		bl.PlaceByte(b.tab.Bytes[pos-1])
+	}
+}
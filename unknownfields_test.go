@@ -0,0 +1,305 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/stretchr/testify/require"
+)
+
+const unknownFieldsSchemeOld = `
+name: string
+qty: int32
+article:
+  id: int64
+lines..:
+  qty: int32
+empty:
+  id: int64
+`
+
+const unknownFieldsSchemeNew = `
+name: string
+qty: int32
+article:
+  id: int64
+  code: string
+lines..:
+  qty: int32
+  comment: string
+empty:
+  id: int64
+  flag: bool
+  tags..: string
+price: float64
+comment: string
+ids..: int64
+extra:
+  id: int64
+  name: string
+extras..:
+  id: int64
+  names..: string
+`
+
+func TestWriteNewRewriteOldReadNew(t *testing.T) {
+	require := require.New(t)
+	sOld, err := YamlToScheme(unknownFieldsSchemeOld)
+	require.NoError(err)
+	sNew, err := YamlToScheme(unknownFieldsSchemeNew)
+	require.NoError(err)
+
+	bNew := NewBuffer(sNew)
+	bytesNew, _, err := bNew.ApplyJSONAndToBytes([]byte(`{
+		"name": "order",
+		"qty": 1,
+		"article": {"id": 2, "code": "art2"},
+		"lines": [{"qty": 3, "comment": "line0"}, {"qty": 4}, {"qty": 5, "comment": "line2"}],
+		"empty": {"flag": true, "tags": ["a", "b"]},
+		"price": 1.5,
+		"comment": "new",
+		"ids": [6, 7],
+		"extra": {"id": 8, "name": "extra8"},
+		"extras": [{"id": 9, "names": ["x", "y"]}, {"id": 10}]
+	}`))
+	require.NoError(err)
+	bytesNew = copyBytes(bytesNew)
+	bNew.Release()
+	bNew = ReadBuffer(bytesNew, sNew)
+	expectedJSON := string(bNew.ToJSON())
+	bNew.Release()
+
+	// rewrite in the old scheme
+	bOld := ReadBuffer(bytesNew, sOld)
+	bytesRewritten, err := bOld.ToBytes()
+	require.NoError(err)
+	bytesRewritten = copyBytes(bytesRewritten)
+	bOld.Release()
+
+	bNew = ReadBuffer(bytesRewritten, sNew)
+	require.JSONEq(expectedJSON, string(bNew.ToJSON()))
+	bNew.Release()
+
+	// modified nested objects and elements keep unknown fields as well
+	bOld = ReadBuffer(bytesRewritten, sOld)
+	bOld.Set("qty", int32(42))
+	bOld.Get("article").(*Buffer).Set("id", int64(20))
+	bOld.GetMutableObjectArray("lines").At(2).Set("qty", int32(50))
+	bOld.GetMutableObjectArray("lines").Remove(1)
+	bytesRewritten2, err := bOld.ToBytes()
+	require.NoError(err)
+	bytesRewritten2 = copyBytes(bytesRewritten2)
+	bOld.Release()
+
+	bNew = ReadBuffer(bytesRewritten2, sNew)
+	require.Equal(int32(42), bNew.Get("qty"))
+	require.Equal(int64(20), bNew.Get("article").(*Buffer).Get("id"))
+	require.Equal("art2", bNew.Get("article").(*Buffer).Get("code"))
+	lines := bNew.Get("lines").(*ObjectArray)
+	require.Equal(2, lines.Len)
+	require.True(lines.Next())
+	require.Equal("line0", lines.Buffer.Get("comment"))
+	require.True(lines.Next())
+	require.Equal(int32(50), lines.Buffer.Get("qty"))
+	require.Equal("line2", lines.Buffer.Get("comment"))
+	require.Equal([]string{"a", "b"}, bNew.Get("empty").(*Buffer).Get("tags"))
+	require.Equal("new", bNew.Get("comment"))
+	require.Equal([]int64{6, 7}, bNew.Get("ids"))
+	require.Equal("extra8", bNew.Get("extra").(*Buffer).Get("name"))
+	bNew.Release()
+
+	// repeated rewrite does not grow the data
+	bOld = ReadBuffer(bytesRewritten, sOld)
+	bytesRewritten3, err := bOld.ToBytes()
+	require.NoError(err)
+	require.Len(bytesRewritten3, len(bytesRewritten))
+	bOld.Release()
+
+	require.Zero(GetObjectsInUse())
+}
+
+func TestUnknownFieldsSharedVtables(t *testing.T) {
+	require := require.New(t)
+	sOld, err := YamlToScheme(`
+name: string
+lines..:
+  qty: int32
+`)
+	require.NoError(err)
+	sNew, err := YamlToScheme(`
+name: string
+lines..:
+  qty: int32
+  article:
+    id: int64
+`)
+	require.NoError(err)
+
+	// nested objects of the unknown field share the vtable written for the first element
+	lines := []string{}
+	for i := 0; i < 100; i++ {
+		lines = append(lines, fmt.Sprintf(`{"qty": %d, "article": {"id": %d}}`, i, i))
+	}
+	bNew := NewBuffer(sNew)
+	bytesNew, _, err := bNew.ApplyJSONAndToBytes([]byte(`{"name": "order", "lines": [` + strings.Join(lines, ",") + `]}`))
+	require.NoError(err)
+	bytesNew = copyBytes(bytesNew)
+	bNew.Release()
+
+	bytes := bytesNew
+	for i := 0; i < 3; i++ {
+		bOld := ReadBuffer(bytes, sOld)
+		bOld.Set("name", fmt.Sprintf("order%d", i))
+		bytes, err = bOld.ToBytes()
+		require.NoError(err)
+		bytes = copyBytes(bytes)
+		bOld.Release()
+		require.Less(len(bytes), len(bytesNew)*3/2)
+	}
+
+	bNew = ReadBuffer(bytes, sNew)
+	require.Equal("order2", bNew.Get("name"))
+	arr := bNew.Get("lines").(*ObjectArray)
+	for i := 0; arr.Next(); i++ {
+		require.Equal(int32(i), arr.Buffer.Get("qty"))
+		require.Equal(int64(i), arr.Buffer.Get("article").(*Buffer).Get("id"))
+	}
+
+	// modified array is re-encoded element by element
+	bOld := ReadBuffer(bytesNew, sOld)
+	bOld.GetMutableObjectArray("lines").At(10).Set("qty", int32(-1))
+	bytes, err = bOld.ToBytes()
+	require.NoError(err)
+	bytes = copyBytes(bytes)
+	bOld.Release()
+	bNew.Release()
+	bNew = ReadBuffer(bytes, sNew)
+	arr = bNew.Get("lines").(*ObjectArray)
+	for i := 0; arr.Next(); i++ {
+		if i == 10 {
+			require.Equal(int32(-1), arr.Buffer.Get("qty"))
+		}
+		require.Equal(int64(i), arr.Buffer.Get("article").(*Buffer).Get("id"))
+	}
+	bNew.Release()
+
+	require.Zero(GetObjectsInUse())
+}
+
+func TestUnknownFieldsAlignment(t *testing.T) {
+	require := require.New(t)
+	sOld, err := YamlToScheme(unknownFieldsSchemeOld)
+	require.NoError(err)
+	sNew, err := YamlToScheme(unknownFieldsSchemeNew)
+	require.NoError(err)
+
+	requireAligned := func(bytes []byte) {
+		t.Helper()
+		require.Zero(len(bytes) % 8)
+		b := ReadBuffer(bytes, sNew)
+		defer b.Release()
+		require.Equal(1.5, b.Get("price"))
+		require.Zero(b.getFieldUOffsetT("price")%8, "float64")
+		ids := b.getFieldUOffsetT("ids")
+		require.Zero(b.tab.Vector(ids-b.tab.Pos)%8, "int64 array")
+		extra := b.Get("extra").(*Buffer)
+		require.Equal(int64(8), extra.Get("id"))
+		require.Zero(extra.getFieldUOffsetT("id")%8, "int64 of a nested object")
+		extras := b.Get("extras").(*ObjectArray)
+		for extras.Next() {
+			require.Zero(extras.Buffer.getFieldUOffsetT("id")%8, "int64 of an array element")
+		}
+	}
+
+	// odd-sized known data shifts the unknown fields data on rewrite
+	bNew := NewBuffer(sNew)
+	bytes, _, err := bNew.ApplyJSONAndToBytes([]byte(`{
+		"name": "o",
+		"qty": 1,
+		"price": 1.5,
+		"ids": [6, 7],
+		"extra": {"id": 8, "name": "extra8"},
+		"extras": [{"id": 9, "names": ["x"]}, {"id": 10}]
+	}`))
+	require.NoError(err)
+	bytes = copyBytes(bytes)
+	bNew.Release()
+	requireAligned(bytes)
+
+	for _, name := range []string{"order", "order12"} {
+		bOld := ReadBuffer(bytes, sOld)
+		bOld.Set("name", name)
+		bytes, err = bOld.ToBytes()
+		require.NoError(err)
+		bytes = copyBytes(bytes)
+		bOld.Release()
+		requireAligned(bytes)
+	}
+
+	require.Zero(GetObjectsInUse())
+}
+
+func TestUnknownFieldsForeignLayout(t *testing.T) {
+	require := require.New(t)
+	sOld, err := YamlToScheme("name: string\nqty: int32")
+	require.NoError(err)
+	sNew, err := YamlToScheme("name: string\nqty: int32\ncomment: string")
+	require.NoError(err)
+
+	// flatc generated code order: data is written in order of creation, slots are added by size desc and in reverse declaration order
+	bl := flatbuffers.NewBuilder(0)
+	comment := bl.CreateString("unknown")
+	name := bl.CreateString("cola")
+	bl.StartObject(3)
+	bl.PrependUOffsetTSlot(2, comment, 0)
+	bl.PrependInt32Slot(1, 42, 0)
+	bl.PrependUOffsetTSlot(0, name, 0)
+	bl.Finish(bl.EndObject())
+	foreign := bl.FinishedBytes()
+
+	bNew := ReadBuffer(foreign, sNew)
+	require.Equal("unknown", bNew.Get("comment"))
+	bNew.Release()
+
+	// layout is not recognized -> unknown fields are dropped, known ones are kept
+	bOld := ReadBuffer(foreign, sOld)
+	require.False(isWriterLayout(sOld, bOld.tab))
+	bOld.Set("qty", int32(43))
+	bytes, err := bOld.ToBytes()
+	require.NoError(err)
+	bNew = ReadBuffer(bytes, sNew)
+	require.Equal("cola", bNew.Get("name"))
+	require.Equal(int32(43), bNew.Get("qty"))
+	require.False(bNew.HasValue("comment"))
+	bNew.Release()
+	bOld.Release()
+
+	// the same data written by this library is recognized
+	bNew = NewBuffer(sNew)
+	bNew.Set("name", "cola")
+	bNew.Set("qty", int32(42))
+	bNew.Set("comment", "unknown")
+	bytes, err = bNew.ToBytes()
+	require.NoError(err)
+	bOld = ReadBuffer(bytes, sOld)
+	require.True(isWriterLayout(sOld, bOld.tab))
+	bOld.Set("qty", int32(43))
+	bytes, err = bOld.ToBytes()
+	require.NoError(err)
+	bNew.Release()
+	bNew = ReadBuffer(bytes, sNew)
+	require.Equal("unknown", bNew.Get("comment"))
+	bNew.Release()
+	bOld.Release()
+
+	require.Zero(GetObjectsInUse())
+}
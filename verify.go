@@ -0,0 +1,177 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"errors"
+	"fmt"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// ErrInvalidBuffer is returned by Verify() if bytes could not be safely read using the Scheme
+var ErrInvalidBuffer = errors.New("invalid buffer")
+
+// VerifyOptions limits resources spent on verification of untrusted bytes
+type VerifyOptions struct {
+	// MaxDepth is the max nesting level of objects, the root object is level 1
+	MaxDepth int
+	// MaxTables is the max number of objects including the root and array elements. Objects referenced many times are counted each time
+	MaxTables int
+}
+
+// DefaultVerifyOptions are used by Verify() and ReadBufferVerified()
+var DefaultVerifyOptions = VerifyOptions{
+	MaxDepth:  64,
+	MaxTables: 1000000,
+}
+
+type verifier struct {
+	bytes  []byte
+	opts   VerifyOptions
+	tables int
+}
+
+// Verify checks that bytes could be read using the Scheme without panics: offsets, vtables, strings and arrays are within bytes, nesting depth and
+// objects count are within DefaultVerifyOptions. Data of fields which are unknown for the Scheme is not checked except the vtable
+// Returns nil for empty bytes. Error wraps ErrInvalidBuffer
+func Verify(bytes []byte, scheme *Scheme) error {
+	return VerifyWithOptions(bytes, scheme, DefaultVerifyOptions)
+}
+
+// VerifyWithOptions is Verify() using custom limits
+func VerifyWithOptions(bytes []byte, scheme *Scheme, opts VerifyOptions) error {
+	if len(bytes) == 0 {
+		return nil
+	}
+	v := verifier{bytes: bytes, opts: opts}
+	if !v.inBounds(0, flatbuffers.SizeUOffsetT) {
+		return fmt.Errorf("%w: %d bytes is too short for the root offset", ErrInvalidBuffer, len(bytes))
+	}
+	return v.verifyTable(scheme, int64(flatbuffers.GetUOffsetT(bytes)), 1)
+}
+
+// ReadBufferVerified creates Buffer from bytes using provided Scheme if bytes pass Verify()
+// Use it for bytes which come from untrusted sources, e.g. from the network. ReadBuffer() could panic on the first read of malformed bytes
+func ReadBufferVerified(bytes []byte, scheme *Scheme) (*Buffer, error) {
+	return ReadBufferWithOptions(bytes, scheme, WithVerification(DefaultVerifyOptions))
+}
+
+func (v *verifier) inBounds(pos int64, size int64) bool {
+	return pos >= 0 && size >= 0 && pos+size <= int64(len(v.bytes))
+}
+
+// verifyTable checks the table and its known fields recursively
+func (v *verifier) verifyTable(s *Scheme, pos int64, depth int) error {
+	if depth > v.opts.MaxDepth {
+		return fmt.Errorf("%w: nesting depth exceeds %d", ErrInvalidBuffer, v.opts.MaxDepth)
+	}
+	if v.tables++; v.tables > v.opts.MaxTables {
+		return fmt.Errorf("%w: objects count exceeds %d", ErrInvalidBuffer, v.opts.MaxTables)
+	}
+	if !v.inBounds(pos, flatbuffers.SizeSOffsetT) {
+		return fmt.Errorf("%w: object at %d is out of bounds", ErrInvalidBuffer, pos)
+	}
+	vtable := pos - int64(flatbuffers.GetSOffsetT(v.bytes[pos:]))
+	if !v.inBounds(vtable, 2*flatbuffers.SizeVOffsetT) {
+		return fmt.Errorf("%w: vtable of object at %d is out of bounds", ErrInvalidBuffer, pos)
+	}
+	vtableSize := int64(flatbuffers.GetVOffsetT(v.bytes[vtable:]))
+	objectSize := int64(flatbuffers.GetVOffsetT(v.bytes[vtable+flatbuffers.SizeVOffsetT:]))
+	if vtableSize < 2*flatbuffers.SizeVOffsetT || vtableSize%flatbuffers.SizeVOffsetT != 0 || !v.inBounds(vtable, vtableSize) {
+		return fmt.Errorf("%w: wrong vtable size %d of object at %d", ErrInvalidBuffer, vtableSize, pos)
+	}
+	if objectSize < flatbuffers.SizeSOffsetT || !v.inBounds(pos, objectSize) {
+		return fmt.Errorf("%w: wrong size %d of object at %d", ErrInvalidBuffer, objectSize, pos)
+	}
+	inlineEnd := pos + objectSize
+	for slot := int64(2); slot < vtableSize/flatbuffers.SizeVOffsetT; slot++ {
+		// unknown fields are copied on ToBytes() as a range of the inline data, so all slots must be within the object
+		vOffsetT := int64(flatbuffers.GetVOffsetT(v.bytes[vtable+slot*flatbuffers.SizeVOffsetT:]))
+		if vOffsetT != 0 && (vOffsetT < flatbuffers.SizeSOffsetT || vOffsetT >= objectSize) {
+			return fmt.Errorf("%w: slot %d of object at %d is out of the object", ErrInvalidBuffer, slot-2, pos)
+		}
+	}
+	for _, f := range s.Fields {
+		slot := int64((f.Order + 2) * flatbuffers.SizeVOffsetT)
+		if slot >= vtableSize {
+			continue
+		}
+		vOffsetT := int64(flatbuffers.GetVOffsetT(v.bytes[vtable+slot:]))
+		if vOffsetT == 0 {
+			continue
+		}
+		if err := v.verifyField(f, pos+vOffsetT, inlineEnd, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *verifier) verifyField(f *Field, fieldPos int64, inlineEnd int64, depth int) error {
+	if !f.IsArray && f.Ft != FieldTypeString && f.Ft != FieldTypeObject {
+		if fieldPos+int64(fieldTypeSize(f.Ft)) > inlineEnd {
+			return fmt.Errorf("%w: field %s is out of the object", ErrInvalidBuffer, f.QualifiedName())
+		}
+		return nil
+	}
+	if fieldPos+flatbuffers.SizeUOffsetT > inlineEnd {
+		return fmt.Errorf("%w: field %s is out of the object", ErrInvalidBuffer, f.QualifiedName())
+	}
+	// data of a field is written before its object so it is located after the field. Object size could not be used here: objects with equal
+	// slots share the vtable which keeps size of the object it is written for
+	uOffsetT := int64(flatbuffers.GetUOffsetT(v.bytes[fieldPos:]))
+	if uOffsetT == 0 {
+		return fmt.Errorf("%w: zero offset of field %s", ErrInvalidBuffer, f.QualifiedName())
+	}
+	target := fieldPos + uOffsetT
+	switch {
+	case f.Ft == FieldTypeObject && !f.IsArray:
+		return v.verifyTable(f.FieldScheme, target, depth+1)
+	case f.Ft == FieldTypeString && !f.IsArray, f.Ft == FieldTypeByte:
+		_, err := v.verifyVector(f, target, 1)
+		return err
+	case f.Ft == FieldTypeString, f.Ft == FieldTypeObject:
+		l, err := v.verifyVector(f, target, flatbuffers.SizeUOffsetT)
+		if err != nil {
+			return err
+		}
+		elemsEnd := target + flatbuffers.SizeUOffsetT + l*flatbuffers.SizeUOffsetT
+		for i := int64(0); i < l; i++ {
+			elemPos := target + flatbuffers.SizeUOffsetT + i*flatbuffers.SizeUOffsetT
+			elem := elemPos + int64(flatbuffers.GetUOffsetT(v.bytes[elemPos:]))
+			if elem < elemsEnd {
+				return fmt.Errorf("%w: element %d of field %s overlaps the array", ErrInvalidBuffer, i, f.QualifiedName())
+			}
+			if f.Ft == FieldTypeObject {
+				err = v.verifyTable(f.FieldScheme, elem, depth+1)
+			} else {
+				_, err = v.verifyVector(f, elem, 1)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		_, err := v.verifyVector(f, target, int64(fieldTypeSize(f.Ft)))
+		return err
+	}
+}
+
+// verifyVector checks the length prefix and elements of a vector or a string, returns the length
+func (v *verifier) verifyVector(f *Field, pos int64, elemSize int64) (int64, error) {
+	if !v.inBounds(pos, flatbuffers.SizeUOffsetT) {
+		return 0, fmt.Errorf("%w: data of field %s is out of bounds", ErrInvalidBuffer, f.QualifiedName())
+	}
+	l := int64(flatbuffers.GetUOffsetT(v.bytes[pos:]))
+	if !v.inBounds(pos+flatbuffers.SizeUOffsetT, l*elemSize) {
+		return 0, fmt.Errorf("%w: length %d of field %s exceeds bounds", ErrInvalidBuffer, l, f.QualifiedName())
+	}
+	return l, nil
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) 2021-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package dynobuffers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(orderSchemeYaml)
+	require.NoError(err)
+	orderBytes := getOrderBytes(t, s)
+
+	require.NoError(Verify(orderBytes, s))
+	require.NoError(Verify(nil, s))
+	b, err := ReadBufferVerified(orderBytes, s)
+	require.NoError(err)
+	require.Equal("order", b.Get("name"))
+	b.Release()
+
+	// truncated. The tail could be padding or string terminator so few last bytes could be cut off safely
+	for l := 1; l < len(orderBytes)-4; l++ {
+		b, err := ReadBufferVerified(orderBytes[:l], s)
+		require.ErrorIs(err, ErrInvalidBuffer, l)
+		require.Nil(b)
+	}
+
+	// limits
+	err = VerifyWithOptions(orderBytes, s, VerifyOptions{MaxDepth: 2, MaxTables: 100})
+	require.ErrorIs(err, ErrInvalidBuffer)
+	require.Contains(err.Error(), "depth")
+	err = VerifyWithOptions(orderBytes, s, VerifyOptions{MaxDepth: 3, MaxTables: 5})
+	require.ErrorIs(err, ErrInvalidBuffer)
+	require.Contains(err.Error(), "count")
+	require.NoError(VerifyWithOptions(orderBytes, s, VerifyOptions{MaxDepth: 3, MaxTables: 6}))
+
+	require.Zero(GetObjectsInUse())
+}
+
+func TestVerifyCorrupted(t *testing.T) {
+	require := require.New(t)
+	s, err := YamlToScheme(orderSchemeYaml)
+	require.NoError(err)
+	orderBytes := getOrderBytes(t, s)
+	sOld, err := YamlToScheme(unknownFieldsSchemeOld)
+	require.NoError(err)
+	sNew, err := YamlToScheme(unknownFieldsSchemeNew)
+	require.NoError(err)
+	bNew := NewBuffer(sNew)
+	newBytes, _, err := bNew.ApplyJSONAndToBytes([]byte(`{
+		"name": "order",
+		"article": {"id": 2, "code": "art2"},
+		"lines": [{"qty": 3, "comment": "line0"}, {"qty": 4}],
+		"comment": "new",
+		"ids": [6, 7],
+		"extra": {"id": 8, "name": "extra8"},
+		"extras": [{"id": 9, "names": ["x", "y"]}, {"id": 10}]
+	}`))
+	require.NoError(err)
+	newBytes = copyBytes(newBytes)
+	bNew.Release()
+	require.NoError(Verify(newBytes, sOld))
+
+	// any corrupted bytes which pass the verification are read and rewritten without panics
+	for _, c := range []struct {
+		bytes []byte
+		s     *Scheme
+	}{{orderBytes, s}, {newBytes, sOld}, {newBytes, sNew}} {
+		for pos := range c.bytes {
+			for _, val := range []byte{0x00, 0x01, 0x04, 0x7f, 0x80, 0xff} {
+				corrupted := copyBytes(c.bytes)
+				corrupted[pos] = val
+				b, err := ReadBufferVerified(corrupted, c.s)
+				if err != nil {
+					require.ErrorIs(err, ErrInvalidBuffer)
+					continue
+				}
+				require.NotPanics(func() {
+					b.ToJSON()
+					_, err := b.ToBytes()
+					require.NoError(err)
+				}, "pos %d, value %d", pos, val)
+				b.Release()
+			}
+		}
+	}
+
+	require.Zero(GetObjectsInUse())
+}